@@ -5,9 +5,10 @@ import (
 	"os/signal"
 	"syscall"
 
-	"lrcsnc/internal/config"
+	"lrcsnc/internal/control"
 	"lrcsnc/internal/mpris"
-	"lrcsnc/internal/output/piped"
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/output"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
 	"lrcsnc/internal/setup"
@@ -28,13 +29,30 @@ func Start() {
 	go func() {
 		for {
 			<-usr1Sig
-			config.Update()
+			_, _ = control.Send(control.CommandReload, nil)
 		}
 	}()
 
+	// Restore the offset adjustments made in the previous sessions
+	_ = offset.Load(global.Config.C.Lyrics.OffsetProfiles.StateFile)
+
 	// Deploy the main watchers
 	sync.Start()
 
+	// Start listening for control commands
+	if global.Config.C.Control.Enabled {
+		if err := control.Listen(global.Config.C.Control.Socket); err == nil {
+			defer control.Close()
+		}
+	}
+
+	// Initialize the output before connecting to MPRIS
+	// so that it doesn't miss the initial player state
+	if err := output.Init(); err != nil {
+		log.Fatal("cmd", "Error when initializing the outputs. Check logs for more info.")
+	}
+	defer output.Close()
+
 	// Initialize the player listener session
 	err := mpris.Connect()
 	if err != nil {
@@ -42,19 +60,9 @@ func Start() {
 	}
 	defer mpris.Disconnect()
 
-	// Initialize the output
-	switch global.Config.C.Output.Type {
-	case "piped":
-		piped.Init()
-		defer piped.Close()
-
-		exitSigs := make(chan os.Signal, 1)
-		signal.Notify(exitSigs, syscall.SIGINT, syscall.SIGTERM)
+	exitSigs := make(chan os.Signal, 1)
+	signal.Notify(exitSigs, syscall.SIGINT, syscall.SIGTERM)
 
-		log.Info("cmd", "Piped output initialized.")
-
-		<-exitSigs
-		log.Info("cmd", "Exit signal received, bye!")
-		os.Exit(0)
-	}
+	<-exitSigs
+	log.Info("cmd", "Exit signal received, bye!")
 }
@@ -12,6 +12,7 @@ require (
 	github.com/charmbracelet/lipgloss v1.0.0
 	github.com/godbus/dbus/v5 v5.1.0
 	github.com/jessevdk/go-flags v1.6.1
+	github.com/mattn/go-runewidth v0.0.16
 	github.com/mozillazg/go-pinyin v0.20.0
 	github.com/pelletier/go-toml/v2 v2.2.3
 	github.com/srevinsaju/korean-romanizer-go v0.0.2
@@ -26,7 +27,6 @@ require (
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-localereader v0.0.1 // indirect
-	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/muesli/ansi v0.0.0-20230316100256-276c6243b2f6 // indirect
 	github.com/muesli/cancelreader v0.2.2 // indirect
 	github.com/muesli/termenv v0.15.2 // indirect
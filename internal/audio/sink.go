@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/log"
+)
+
+// DefaultSink returns the name of the default audio sink of PulseAudio
+// (or PipeWire through pipewire-pulse), e.g. "bluez_output.00_11_22_33_44_55.1".
+func DefaultSink() (string, error) {
+	out, err := pactl("info").Output()
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if sink, ok := strings.CutPrefix(scanner.Text(), "Default Sink: "); ok {
+			return strings.TrimSpace(sink), nil
+		}
+	}
+
+	return "", errs.ErrNoDefaultSink
+}
+
+// WatchSink sends the name of the default audio sink to the channel
+// right away and then every time it changes. It blocks until the sound server
+// stops sending events (e.g. on its restart) or done is closed,
+// so it's meant to be run in a goroutine. The channel is closed once it returns.
+func WatchSink(ch chan<- string, done <-chan bool) error {
+	defer close(ch)
+
+	cmd := pactl("subscribe")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer cmd.Wait()
+
+	// Killing pactl ends its output, which is what stops the watching
+	stopped := make(chan bool)
+	defer close(stopped)
+	go func() {
+		select {
+		case <-done:
+			cmd.Process.Kill()
+		case <-stopped:
+		}
+	}()
+
+	last := ""
+	check := func() {
+		sink, err := DefaultSink()
+		if err != nil {
+			log.Error("audio/WatchSink", "Couldn't get the default sink: "+err.Error())
+			return
+		}
+		if sink != last {
+			last = sink
+			ch <- sink
+		}
+	}
+
+	check()
+
+	// The default sink change is reported as a change on the server,
+	// though it's also worth checking when a sink comes or goes
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "on server") || (strings.Contains(line, "on sink #") && !strings.Contains(line, "'change'")) {
+			check()
+		}
+	}
+
+	return scanner.Err()
+}
+
+// pactl prepares a pactl command with the output that doesn't depend on the locale
+func pactl(args ...string) *exec.Cmd {
+	cmd := exec.Command("pactl", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
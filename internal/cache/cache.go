@@ -123,6 +123,54 @@ func Remove(song *structs.Song) error {
 	return nil
 }
 
+// StoreOffset saves the song's own timestamp offset in its cache entry.
+// The age of the entry is left as is, so the correction doesn't prolong the life of the lyrics.
+// If there is no entry yet, the song's lyrics data is stored whole.
+func StoreOffset(song *structs.Song) error {
+	global.Config.M.Lock()
+	enabled := global.Config.C.Cache.Enabled
+	fullPath := getCacheDir() + "/" + getFilename(song) + ".json"
+	global.Config.M.Unlock()
+
+	if !enabled {
+		return errors.ErrCacheDisabled
+	}
+
+	file, err := os.ReadFile(fullPath)
+	if err != nil {
+		log.Debug("cache/StoreOffset", "There is no cache entry for the song yet, storing it whole")
+		return Store(song)
+	}
+
+	var cachedData structs.LyricsData
+	if err := json.Unmarshal(file, &cachedData); err != nil {
+		log.Error("cache/StoreOffset", "Couldn't unmarshal the data, storing the song anew: "+err.Error())
+		return Store(song)
+	}
+	cachedData.Offset = song.LyricsData.Offset
+
+	encodedData, err := json.Marshal(cachedData)
+	if err != nil {
+		log.Error("cache/StoreOffset", "Failed to marshal the data: "+err.Error())
+		return errors.ErrMarshalFail
+	}
+
+	cacheStats, err := os.Lstat(fullPath)
+	if err != nil {
+		return errors.ErrFileUnreachable
+	}
+	if err := os.WriteFile(fullPath, encodedData, 0o644); err != nil {
+		log.Error("cache/StoreOffset", "Failed to write the cache file: "+err.Error())
+		return errors.ErrFileUnwriteable
+	}
+	if err := os.Chtimes(fullPath, time.Time{}, cacheStats.ModTime()); err != nil {
+		log.Warn("cache/StoreOffset", "Couldn't keep the age of the cache file: "+err.Error())
+	}
+
+	log.Debug("cache/StoreOffset", fmt.Sprintf("Stored the song offset of %+.2fs", song.LyricsData.Offset))
+	return nil
+}
+
 func getCacheDir() string {
 	return os.ExpandEnv(global.Config.C.Cache.Dir)
 }
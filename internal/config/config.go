@@ -36,6 +36,16 @@ func Read(path string) error {
 		}
 	}
 
+	if err := inheritOutputSettings(configFile, &config); err != nil {
+		log.Error("config/Read", "Error parsing the output instances: "+err.Error())
+		return errs.ErrConfigFileInvalid
+	}
+
+	// The overrides only affect what's read from the file
+	global.Config.M.Lock()
+	applyOverrides(&config)
+	global.Config.M.Unlock()
+
 	wrongs := Validate(&config)
 	fatal := false
 	for _, v := range wrongs {
@@ -61,6 +71,55 @@ func Read(path string) error {
 	return nil
 }
 
+// inheritOutputSettings makes the output instances use the settings of
+// [output.<type>] for everything they don't set themselves
+func inheritOutputSettings(configFile []byte, config *structs.Config) error {
+	if len(config.Output.Instances) == 0 {
+		return nil
+	}
+
+	// The instances are read again on top of the shared settings,
+	// so only the keys that are actually set override them
+	var raw struct {
+		Output struct {
+			Instances map[string]map[string]any `toml:"instances"`
+		} `toml:"output"`
+	}
+	if err := toml.Unmarshal(configFile, &raw); err != nil {
+		return err
+	}
+
+	for name, instance := range config.Output.Instances {
+		instance.Piped, instance.TUI = config.Output.Piped, config.Output.TUI
+		instance.HTTP, instance.Socket = config.Output.HTTP, config.Output.Socket
+		instance.Notify, instance.MPV, instance.Webhook = config.Output.Notify, config.Output.MPV, config.Output.Webhook
+		for key, settings := range map[string]any{
+			"piped":   &instance.Piped,
+			"tui":     &instance.TUI,
+			"http":    &instance.HTTP,
+			"socket":  &instance.Socket,
+			"notify":  &instance.Notify,
+			"mpv":     &instance.MPV,
+			"webhook": &instance.Webhook,
+		} {
+			set, ok := raw.Output.Instances[name][key]
+			if !ok {
+				continue
+			}
+			data, err := toml.Marshal(set)
+			if err != nil {
+				return err
+			}
+			if err := toml.Unmarshal(data, settings); err != nil {
+				return err
+			}
+		}
+		config.Output.Instances[name] = instance
+	}
+
+	return nil
+}
+
 func ReadUserWide() error {
 	userConfigDir, err := os.UserConfigDir()
 	if err != nil {
@@ -74,12 +133,13 @@ func ReadSystemWide() error {
 	return Read("/etc/lrcsnc/config.toml")
 }
 
-func Update() {
+func Update() error {
 	if global.Config.Path == "default" {
-		return
+		return nil
 	}
 
-	if err := Read(global.Config.Path); err != nil {
+	err := Read(global.Config.Path)
+	if err != nil {
 		switch {
 		case errors.Is(err, errs.ErrFileUnreachable):
 			log.Error("config/Update", "The config file is now unreachable. The configuration will remain the same until restart or until the config file reappears.")
@@ -87,4 +147,6 @@ func Update() {
 			log.Error("config/Update", "Unknown error: "+err.Error())
 		}
 	}
+
+	return err
 }
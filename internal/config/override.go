@@ -0,0 +1,28 @@
+package config
+
+import (
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+)
+
+// overrides are the changes made to every config that is read,
+// e.g. the ones requested by the command line flags
+var overrides []func(c *structs.Config)
+
+// Override applies the change to the current config and to every config read later,
+// so that reloading the config doesn't undo it.
+func Override(f func(c *structs.Config)) {
+	global.Config.M.Lock()
+	defer global.Config.M.Unlock()
+
+	overrides = append(overrides, f)
+	f(&global.Config.C)
+}
+
+// applyOverrides applies all the overrides to the config.
+// Does NOT lock the config mutex.
+func applyOverrides(c *structs.Config) {
+	for _, f := range overrides {
+		f(c)
+	}
+}
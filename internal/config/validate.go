@@ -1,11 +1,19 @@
 package config
 
 import (
+	"encoding/xml"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
 	"lrcsnc/internal/pkg/types"
 )
 
@@ -24,14 +32,7 @@ type ValidationErrors []ValidationError
 func Validate(c *structs.Config) (errs ValidationErrors) {
 	errs = make(ValidationErrors, 0)
 
-	// Check whether output value is allowed
-	if c.Output.Type != "piped" {
-		errs = append(errs, ValidationError{
-			Path:    "global/output",
-			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'piped' (will be more in the future)", c.Output.Type),
-			Fatal:   true,
-		})
-	}
+	errs = append(errs, validateOutputs(c)...)
 
 	// Check whether lrclib is set as the lyrics provider
 	if c.Lyrics.Provider != "lrclib" {
@@ -42,45 +43,83 @@ func Validate(c *structs.Config) (errs ValidationErrors) {
 		})
 	}
 
-	// Check if piped output's destination is writeable if it's not stdout
-	if c.Output.Type == "piped" && c.Output.Piped.Destination != "stdout" && !isPathWriteable(c.Output.Piped.Destination) {
+	// Check if the tick interval is set to <0.05s (0 disables the ticks)
+	if c.Output.TickInterval != 0 && c.Output.TickInterval < 0.05 {
 		errs = append(errs, ValidationError{
-			Path:    "output/piped/destination",
-			Message: fmt.Sprintf("'%s' is not a writeable path. Please make sure the path exists and is writeable", c.Output.Piped.Destination),
-			Fatal:   true,
+			Path:    "output/tick-interval",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0.05s)", c.Output.TickInterval),
+			Fatal:   false,
 		})
+		c.Output.TickInterval = 0.05
 	}
 
-	// Check if JSON output type chosen is valid
-	if c.Output.Type == "piped" && (c.Output.Piped.JSON != types.JSONOutputNone &&
-		c.Output.Piped.JSON != types.JSONOutputGeneric &&
-		c.Output.Piped.JSON != types.JSONOutputWaybar) {
+	// Check if the intro behaviour is valid (an empty one is just the default)
+	if c.Output.Intro.Behaviour != "" &&
+		c.Output.Intro.Behaviour != types.IntroInstrumental &&
+		c.Output.Intro.Behaviour != types.IntroTitle &&
+		c.Output.Intro.Behaviour != types.IntroCountdown &&
+		c.Output.Intro.Behaviour != types.IntroEmpty {
 		errs = append(errs, ValidationError{
-			Path:    "output/piped/json",
-			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'none', 'generic' and 'waybar'. Will use 'none' from now.", c.Output.Piped.JSON),
+			Path:    "output/intro/behaviour",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'instrumental', 'title', 'countdown' and 'empty'. Will use 'instrumental' from now.", c.Output.Intro.Behaviour),
 			Fatal:   false,
 		})
-		c.Output.Piped.JSON = types.JSONOutputNone
+		c.Output.Intro.Behaviour = types.IntroInstrumental
 	}
 
-	// Check if the instrumental interval is set to <0.1s
-	if c.Output.Type == "piped" && c.Output.Piped.Instrumental.Interval < 0.1 {
+	errs = append(errs, validateTemplate("output/intro/format", c.Output.Intro.Format, template.PipedNames)...)
+
+	// Check if the outro behaviour is valid (an empty one is just the default)
+	if c.Output.Outro.Behaviour != "" &&
+		c.Output.Outro.Behaviour != types.OutroHold &&
+		c.Output.Outro.Behaviour != types.OutroClear &&
+		c.Output.Outro.Behaviour != types.OutroEnd {
 		errs = append(errs, ValidationError{
-			Path:    "output/piped/instrumental/interval",
-			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0.1s)", c.Output.Piped.Instrumental.Interval),
+			Path:    "output/outro/behaviour",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'hold', 'clear' and 'end'. Will use 'hold' from now.", c.Output.Outro.Behaviour),
 			Fatal:   false,
 		})
-		c.Output.Piped.Instrumental.Interval = 0.1
+		c.Output.Outro.Behaviour = types.OutroHold
 	}
 
-	// Check if max symbols is less than 1
-	if c.Output.Type == "piped" && c.Output.Piped.Instrumental.MaxSymbols < 1 {
+	// Check if the outro delay is negative
+	if c.Output.Outro.After < 0 {
+		errs = append(errs, ValidationError{
+			Path:    "output/outro/after",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0s)", c.Output.Outro.After),
+			Fatal:   false,
+		})
+		c.Output.Outro.After = 0
+	}
+
+	// Check if the drift check interval is set to <1s
+	if c.Player.DriftCorrection.Enabled && c.Player.DriftCorrection.Interval < 1 {
 		errs = append(errs, ValidationError{
-			Path:    "output/piped/instrumental/max-symbols",
-			Message: fmt.Sprintf("'%d' is not a valid value. Using the possible minimum instead (1)", c.Output.Piped.Instrumental.MaxSymbols),
+			Path:    "player/drift-correction/interval",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (1s)", c.Player.DriftCorrection.Interval),
 			Fatal:   false,
 		})
-		c.Output.Piped.Instrumental.MaxSymbols = 1
+		c.Player.DriftCorrection.Interval = 1
+	}
+
+	// Check if the drift threshold is set to <0.05s
+	if c.Player.DriftCorrection.Enabled && c.Player.DriftCorrection.Threshold < 0.05 {
+		errs = append(errs, ValidationError{
+			Path:    "player/drift-correction/threshold",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0.05s)", c.Player.DriftCorrection.Threshold),
+			Fatal:   false,
+		})
+		c.Player.DriftCorrection.Threshold = 0.05
+	}
+
+	// Check if the control socket path is set when the control socket is enabled
+	if c.Control.Enabled && c.Control.Socket == "" {
+		errs = append(errs, ValidationError{
+			Path:    "control/socket",
+			Message: "The control socket path is empty. The control socket will be disabled",
+			Fatal:   false,
+		})
+		c.Control.Enabled = false
 	}
 
 	return
@@ -96,3 +135,661 @@ func isPathWriteable(p string) bool {
 		return true
 	}
 }
+
+// validateOutputs checks the settings of every output,
+// either the configured instances or the single one
+func validateOutputs(c *structs.Config) (errs ValidationErrors) {
+	if len(c.Output.Instances) == 0 {
+		// Check whether output value is allowed
+		if !isOutputType(c.Output.Type) {
+			errs = append(errs, ValidationError{
+				Path:    "global/output",
+				Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'piped', 'dbus', 'tui', 'http', 'socket', 'notify', 'mpv' and 'webhook'", c.Output.Type),
+				Fatal:   true,
+			})
+		}
+
+		switch c.Output.Type {
+		case types.OutputPiped:
+			errs = append(errs, validatePiped("output/piped", &c.Output.Piped)...)
+		case types.OutputTUI:
+			errs = append(errs, validateTUI("output/tui", &c.Output.TUI)...)
+		case types.OutputHTTP:
+			errs = append(errs, validateHTTP("output/http", &c.Output.HTTP)...)
+		case types.OutputSocket:
+			errs = append(errs, validateSocket("output/socket", &c.Output.Socket, c)...)
+		case types.OutputNotify:
+			errs = append(errs, validateNotify("output/notify", &c.Output.Notify)...)
+		case types.OutputMPV:
+			errs = append(errs, validateMPV("output/mpv", &c.Output.MPV)...)
+		case types.OutputWebhook:
+			errs = append(errs, validateWebhook("output/webhook", &c.Output.Webhook)...)
+		}
+		return
+	}
+
+	names := make([]string, 0, len(c.Output.Instances))
+	for name := range c.Output.Instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := make(map[types.OutputType]int)
+	destinations := make(map[string]string)
+	addresses := make(map[string]string)
+	sockets := make(map[string]string)
+	for _, name := range names {
+		instance := c.Output.Instances[name]
+		path := "output/instances/" + name
+
+		// Check whether the instance's type is allowed
+		if !isOutputType(instance.Type) {
+			errs = append(errs, ValidationError{
+				Path:    path + "/type",
+				Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'piped', 'dbus', 'tui', 'http', 'socket', 'notify', 'mpv' and 'webhook'", instance.Type),
+				Fatal:   true,
+			})
+		}
+		counts[instance.Type]++
+
+		switch instance.Type {
+		case types.OutputPiped:
+			errs = append(errs, validatePiped(path+"/piped", &instance.Piped)...)
+
+			// Check if two piped outputs write to the same destination
+			if other, ok := destinations[instance.Piped.Destination]; ok {
+				errs = append(errs, ValidationError{
+					Path:    path + "/piped/destination",
+					Message: fmt.Sprintf("'%s' is already the destination of the '%s' output. Every piped output needs its own destination", instance.Piped.Destination, other),
+					Fatal:   true,
+				})
+			}
+			destinations[instance.Piped.Destination] = name
+		case types.OutputTUI:
+			errs = append(errs, validateTUI(path+"/tui", &instance.TUI)...)
+		case types.OutputHTTP:
+			errs = append(errs, validateHTTP(path+"/http", &instance.HTTP)...)
+
+			// Check if two HTTP outputs listen on the same address
+			if other, ok := addresses[instance.HTTP.Address]; ok {
+				errs = append(errs, ValidationError{
+					Path:    path + "/http/address",
+					Message: fmt.Sprintf("'%s' is already the address of the '%s' output. Every HTTP output needs its own address", instance.HTTP.Address, other),
+					Fatal:   true,
+				})
+			}
+			addresses[instance.HTTP.Address] = name
+		case types.OutputSocket:
+			errs = append(errs, validateSocket(path+"/socket", &instance.Socket, c)...)
+
+			// Check if two socket outputs publish on the same path
+			if other, ok := sockets[instance.Socket.Path]; ok {
+				errs = append(errs, ValidationError{
+					Path:    path + "/socket/path",
+					Message: fmt.Sprintf("'%s' is already the path of the '%s' output. Every socket output needs its own path", instance.Socket.Path, other),
+					Fatal:   true,
+				})
+			}
+			sockets[instance.Socket.Path] = name
+		case types.OutputNotify:
+			errs = append(errs, validateNotify(path+"/notify", &instance.Notify)...)
+		case types.OutputMPV:
+			errs = append(errs, validateMPV(path+"/mpv", &instance.MPV)...)
+		case types.OutputWebhook:
+			errs = append(errs, validateWebhook(path+"/webhook", &instance.Webhook)...)
+		}
+
+		c.Output.Instances[name] = instance
+	}
+
+	// The D-Bus service and the terminal UI can't be run twice
+	for _, t := range []types.OutputType{types.OutputDBus, types.OutputTUI} {
+		if counts[t] > 1 {
+			errs = append(errs, ValidationError{
+				Path:    "output/instances",
+				Message: fmt.Sprintf("There are %d '%s' outputs, but only one is allowed", counts[t], t),
+				Fatal:   true,
+			})
+		}
+	}
+
+	// The terminal UI takes the whole terminal, so nothing else can be written to it
+	if counts[types.OutputTUI] > 0 {
+		for _, destination := range []string{"stdout", "/dev/stdout"} {
+			if other, ok := destinations[destination]; ok {
+				errs = append(errs, ValidationError{
+					Path:    "output/instances/" + other + "/piped/destination",
+					Message: fmt.Sprintf("'%s' is the terminal the 'tui' output draws on. Use another destination for this output", destination),
+					Fatal:   true,
+				})
+			}
+		}
+	}
+
+	return
+}
+
+// validatePiped checks the settings of a piped output found at the path
+func validatePiped(path string, p *structs.PipedOutputConfig) (errs ValidationErrors) {
+	// Check if piped output's destination is writeable if it's not stdout
+	if p.Destination != "stdout" && !isPathWriteable(p.Destination) {
+		errs = append(errs, ValidationError{
+			Path:    path + "/destination",
+			Message: fmt.Sprintf("'%s' is not a writeable path. Please make sure the path exists and is writeable", p.Destination),
+			Fatal:   true,
+		})
+	}
+
+	// Check if the formats are valid templates
+	for name, format := range map[string]string{
+		"text/format":                   p.Text.Format,
+		"multiplier/format":             p.Multiplier.Format,
+		"json-waybar/alt":               p.JSONWaybar.Alt,
+		"json-waybar/tooltip":           p.JSONWaybar.Tooltip,
+		"json-waybar/class":             p.JSONWaybar.Class,
+		"instrumental/countdown/format": p.Instrumental.Countdown.Format,
+	} {
+		errs = append(errs, validateTemplate(path+"/"+name, format, template.PipedNames)...)
+	}
+
+	// Check if JSON output type chosen is valid
+	if p.JSON != types.JSONOutputNone &&
+		p.JSON != types.JSONOutputGeneric &&
+		p.JSON != types.JSONOutputWaybar &&
+		p.JSON != types.JSONOutputI3bar {
+		errs = append(errs, ValidationError{
+			Path:    path + "/json",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'none', 'generic', 'waybar' and 'i3bar'. Will use 'none' from now.", p.JSON),
+			Fatal:   false,
+		})
+		p.JSON = types.JSONOutputNone
+	}
+
+	// Check if the Waybar percentage is valid (an empty one is just the default)
+	if p.JSONWaybar.Percentage != "" &&
+		p.JSONWaybar.Percentage != types.ProgressNone &&
+		p.JSONWaybar.Percentage != types.ProgressSong &&
+		p.JSONWaybar.Percentage != types.ProgressLine {
+		errs = append(errs, ValidationError{
+			Path:    path + "/json-waybar/percentage",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'none', 'song' and 'line'. Will use 'none' from now.", p.JSONWaybar.Percentage),
+			Fatal:   false,
+		})
+		p.JSONWaybar.Percentage = types.ProgressNone
+	}
+
+	if p.JSON == types.JSONOutputWaybar {
+		errs = append(errs, validateMarkup(path+"/json-waybar/markup", &p.JSONWaybar.Markup)...)
+	}
+
+	if p.JSON == types.JSONOutputI3bar {
+		errs = append(errs, validateI3bar(path+"/json-i3bar", p)...)
+	}
+
+	errs = append(errs, validateOverflow(path+"/overflow", &p.Overflow)...)
+
+	// Check if the instrumental interval is set to <0.1s
+	if p.Instrumental.Interval < 0.1 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/instrumental/interval",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0.1s)", p.Instrumental.Interval),
+			Fatal:   false,
+		})
+		p.Instrumental.Interval = 0.1
+	}
+
+	// Check if max symbols is less than 1
+	if p.Instrumental.MaxSymbols < 1 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/instrumental/max-symbols",
+			Message: fmt.Sprintf("'%d' is not a valid value. Using the possible minimum instead (1)", p.Instrumental.MaxSymbols),
+			Fatal:   false,
+		})
+		p.Instrumental.MaxSymbols = 1
+	}
+
+	// Check if the countdown threshold is set to <1s
+	if p.Instrumental.Countdown.Enabled && p.Instrumental.Countdown.Threshold < 1 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/instrumental/countdown/threshold",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (1s)", p.Instrumental.Countdown.Threshold),
+			Fatal:   false,
+		})
+		p.Instrumental.Countdown.Threshold = 1
+	}
+
+	return
+}
+
+// validateTUI checks the settings of a terminal UI output found at the path
+func validateTUI(path string, t *structs.TUIOutputConfig) (errs ValidationErrors) {
+	// Check if the TUI's seek step is set to <=0s
+	if t.SeekStep <= 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/seek-step",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the default instead (5s)", t.SeekStep),
+			Fatal:   false,
+		})
+		t.SeekStep = 5
+	}
+
+	// Check if the TUI's offset step is set to <=0s
+	if t.OffsetStep <= 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/offset-step",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the default instead (0.1s)", t.OffsetStep),
+			Fatal:   false,
+		})
+		t.OffsetStep = 0.1
+	}
+
+	return
+}
+
+// validateHTTP checks the settings of an HTTP output found at the path
+func validateHTTP(path string, h *structs.HTTPOutputConfig) (errs ValidationErrors) {
+	// Check if the address is set
+	if h.Address == "" {
+		errs = append(errs, ValidationError{
+			Path:    path + "/address",
+			Message: "The address is empty. Using the default instead (127.0.0.1:8686)",
+			Fatal:   false,
+		})
+		h.Address = "127.0.0.1:8686"
+	}
+
+	// Check if the text alignment is valid (an empty one is just the default)
+	if h.Theme.Align != "" && h.Theme.Align != "left" && h.Theme.Align != "center" && h.Theme.Align != "right" {
+		errs = append(errs, ValidationError{
+			Path:    path + "/theme/align",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'left', 'center' and 'right'. Will use 'center' from now.", h.Theme.Align),
+			Fatal:   false,
+		})
+		h.Theme.Align = "center"
+	}
+
+	// Check if the transition is negative
+	if h.Theme.Transition < 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/theme/transition",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0s)", h.Theme.Transition),
+			Fatal:   false,
+		})
+		h.Theme.Transition = 0
+	}
+
+	// Check if the stylesheet is readable
+	if h.Theme.Stylesheet != "" {
+		if _, err := os.ReadFile(os.ExpandEnv(h.Theme.Stylesheet)); err != nil {
+			errs = append(errs, ValidationError{
+				Path:    path + "/theme/stylesheet",
+				Message: fmt.Sprintf("'%s' is not a readable file. The stylesheet will be ignored", h.Theme.Stylesheet),
+				Fatal:   false,
+			})
+			h.Theme.Stylesheet = ""
+		}
+	}
+
+	return
+}
+
+// validateSocket checks the settings of a socket output found at the path
+func validateSocket(path string, s *structs.SocketOutputConfig, c *structs.Config) (errs ValidationErrors) {
+	// Check if the socket path is set
+	if s.Path == "" {
+		errs = append(errs, ValidationError{
+			Path:    path + "/path",
+			Message: "The socket path is empty. Using the default instead ($XDG_RUNTIME_DIR/lrcsnc-events.sock)",
+			Fatal:   false,
+		})
+		s.Path = "$XDG_RUNTIME_DIR/lrcsnc-events.sock"
+	}
+
+	// Check if the socket is not the control one
+	if c.Control.Enabled && s.Path == c.Control.Socket {
+		errs = append(errs, ValidationError{
+			Path:    path + "/path",
+			Message: fmt.Sprintf("'%s' is already the control socket path", s.Path),
+			Fatal:   true,
+		})
+	}
+
+	return
+}
+
+// validateNotify checks the settings of a notification output found at the path
+func validateNotify(path string, n *structs.NotifyOutputConfig) (errs ValidationErrors) {
+	// Check if the mode is valid (an empty one is just the default)
+	if n.Mode != "" && n.Mode != types.NotifyLine && n.Mode != types.NotifySong {
+		errs = append(errs, ValidationError{
+			Path:    path + "/mode",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'line' and 'song'. Will use 'line' from now.", n.Mode),
+			Fatal:   false,
+		})
+		n.Mode = types.NotifyLine
+	}
+
+	// Check if the song mode shows any lines
+	if n.Mode == types.NotifySong && n.Lines < 1 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/lines",
+			Message: fmt.Sprintf("'%d' is not a valid value. Using the possible minimum instead (1)", n.Lines),
+			Fatal:   false,
+		})
+		n.Lines = 1
+	}
+
+	// Check if the urgency is valid (an empty one is just the default)
+	if n.Urgency != "" &&
+		n.Urgency != types.NotifyUrgencyLow &&
+		n.Urgency != types.NotifyUrgencyNormal &&
+		n.Urgency != types.NotifyUrgencyCritical {
+		errs = append(errs, ValidationError{
+			Path:    path + "/urgency",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'low', 'normal' and 'critical'. Will use 'normal' from now.", n.Urgency),
+			Fatal:   false,
+		})
+		n.Urgency = types.NotifyUrgencyNormal
+	}
+
+	errs = append(errs, validateTemplate(path+"/summary", n.Summary, template.SongNames)...)
+
+	return
+}
+
+// validateMPV checks the settings of an mpv output found at the path
+func validateMPV(path string, m *structs.MPVOutputConfig) (errs ValidationErrors) {
+	// Check if mpv's socket path is set
+	if m.Socket == "" {
+		errs = append(errs, ValidationError{
+			Path:    path + "/socket",
+			Message: "mpv's socket path is empty. Using the default instead (/tmp/mpvsocket)",
+			Fatal:   false,
+		})
+		m.Socket = "/tmp/mpvsocket"
+	}
+
+	// An empty player is just mpv
+	if m.Player == "" {
+		m.Player = "mpv"
+	}
+
+	// Check if the duration is set to <=0s
+	if m.Duration <= 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/duration",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the default instead (5s)", m.Duration),
+			Fatal:   false,
+		})
+		m.Duration = 5
+	}
+
+	errs = append(errs, validateTemplate(path+"/format", m.Format, append([]string{"lyric"}, template.SongNames...))...)
+
+	return
+}
+
+// validateTemplate checks that the format found at the path is a valid template
+// that uses only the variables with the names
+func validateTemplate(path, format string, names []string) (errs ValidationErrors) {
+	if err := template.Validate(format, names); err != nil {
+		errs = append(errs, ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("'%s' is not a valid format: %v", format, err),
+			Fatal:   true,
+		})
+	}
+	return
+}
+
+// validateOverflow checks the overflow settings of a piped output found at the path
+func validateOverflow(path string, o *structs.OverflowConfig) (errs ValidationErrors) {
+	// Check if the mode is valid (an empty one is just the default)
+	if o.Mode == "" {
+		o.Mode = types.OverflowTruncate
+	}
+	if o.Mode != types.OverflowTruncate && o.Mode != types.OverflowWrap && o.Mode != types.OverflowMarquee {
+		errs = append(errs, ValidationError{
+			Path:    path + "/mode",
+			Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'truncate', 'wrap' and 'marquee'. Will use 'truncate' from now.", o.Mode),
+			Fatal:   false,
+		})
+		o.Mode = types.OverflowTruncate
+	}
+
+	// Check if the marquee speed is set to <=0
+	if o.Mode == types.OverflowMarquee && o.Speed <= 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/speed",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the default instead (8 cells per second)", o.Speed),
+			Fatal:   false,
+		})
+		o.Speed = 8
+	}
+
+	// Check if the marquee delay is negative
+	if o.Delay < 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/delay",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0s)", o.Delay),
+			Fatal:   false,
+		})
+		o.Delay = 0
+	}
+
+	return
+}
+
+// CheckCommand checks a control command (as used by `lrcsnc ctl`) that is run from the config.
+// The commands belong to the control package, which the config doesn't depend on,
+// so the check is set by the setup; until then every command is taken as valid.
+var CheckCommand = func(args []string) error { return nil }
+
+// validateI3bar checks the i3bar settings of a piped output found at the path
+func validateI3bar(path string, p *structs.PipedOutputConfig) (errs ValidationErrors) {
+	i := &p.JSONI3bar
+
+	// Check if the clicks can be received at all
+	if i.ClickEvents && p.Destination != "stdout" {
+		errs = append(errs, ValidationError{
+			Path:    path + "/click-events",
+			Message: "The click events are only read when the destination is 'stdout'",
+			Fatal:   false,
+		})
+	}
+
+	// Check if the colors are for valid lyrics states and are colors at all
+	colors := make(map[string]string, len(i.Colors))
+	for state, color := range i.Colors {
+		if !isLyricsState(state) {
+			errs = append(errs, ValidationError{
+				Path:    path + "/colors",
+				Message: fmt.Sprintf("'%s' is not a valid lyrics state. Allowed values are 'synced', 'plain', 'instrumental', 'not-found', 'loading' and 'unknown'. It will be ignored", state),
+				Fatal:   false,
+			})
+			continue
+		}
+		if !hexColor.MatchString(color) {
+			errs = append(errs, ValidationError{
+				Path:    path + "/colors/" + state,
+				Message: fmt.Sprintf("'%s' is not a valid color. Use the #RRGGBB or #RRGGBBAA format. It will be ignored", color),
+				Fatal:   false,
+			})
+			continue
+		}
+		colors[state] = color
+	}
+	i.Colors = colors
+
+	// Check if the urgent states are valid lyrics states
+	urgent := make([]string, 0, len(i.Urgent))
+	for _, state := range i.Urgent {
+		if !isLyricsState(state) {
+			errs = append(errs, ValidationError{
+				Path:    path + "/urgent",
+				Message: fmt.Sprintf("'%s' is not a valid lyrics state. Allowed values are 'synced', 'plain', 'instrumental', 'not-found', 'loading' and 'unknown'. It will be ignored", state),
+				Fatal:   false,
+			})
+			continue
+		}
+		urgent = append(urgent, state)
+	}
+	i.Urgent = urgent
+
+	// Check if the clicks are for mouse buttons and are valid commands
+	clicks := make(map[string]string, len(i.Clicks))
+	for button, command := range i.Clicks {
+		if b, err := strconv.Atoi(button); err != nil || b < 1 {
+			errs = append(errs, ValidationError{
+				Path:    path + "/clicks",
+				Message: fmt.Sprintf("'%s' is not a valid mouse button. It will be ignored", button),
+				Fatal:   false,
+			})
+			continue
+		}
+		if err := CheckCommand(strings.Fields(command)); err != nil {
+			errs = append(errs, ValidationError{
+				Path:    path + "/clicks/" + button,
+				Message: fmt.Sprintf("'%s' is not a valid command (%v). It will be ignored", command, err),
+				Fatal:   false,
+			})
+			continue
+		}
+		clicks[button] = command
+	}
+	i.Clicks = clicks
+
+	return
+}
+
+// validateMarkup checks that the markup templates found at the path
+// have a place for the value and are well-formed, so that they don't break every line
+func validateMarkup(path string, m *structs.WaybarMarkupConfig) (errs ValidationErrors) {
+	for name, template := range map[string]*string{
+		"icon":       &m.Icon,
+		"lyric":      &m.Lyric,
+		"multiplier": &m.Multiplier,
+		"message":    &m.Message,
+	} {
+		if *template == "" {
+			continue
+		}
+
+		var message string
+		if !strings.Contains(*template, "{value}") {
+			message = fmt.Sprintf("'%s' has no {value} to put the text in. It will be ignored", *template)
+		} else if err := checkMarkup(strings.ReplaceAll(*template, "{value}", "")); err != nil {
+			message = fmt.Sprintf("'%s' is not valid markup (%v). It will be ignored", *template, err)
+		}
+		if message != "" {
+			errs = append(errs, ValidationError{
+				Path:    path + "/" + name,
+				Message: message,
+				Fatal:   false,
+			})
+			*template = ""
+		}
+	}
+
+	return
+}
+
+// checkMarkup checks that the Pango markup is well-formed
+func checkMarkup(markup string) error {
+	decoder := xml.NewDecoder(strings.NewReader("<markup>" + markup + "</markup>"))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// hexColor matches the #RRGGBB and #RRGGBBAA colors
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}([0-9a-fA-F]{2})?$`)
+
+func isLyricsState(s string) bool {
+	for l := types.LyricsStateSynced; l <= types.LyricsStateUnknown; l++ {
+		if l.String() == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWebhook checks the settings of a webhook output found at the path
+func validateWebhook(path string, w *structs.WebhookOutputConfig) (errs ValidationErrors) {
+	// Check if there is anywhere to send the events to
+	if len(w.URLs) == 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/urls",
+			Message: "There are no URLs to send the events to",
+			Fatal:   true,
+		})
+	}
+
+	// Check if the URLs are HTTP ones
+	for _, u := range w.URLs {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs = append(errs, ValidationError{
+				Path:    path + "/urls",
+				Message: fmt.Sprintf("'%s' is not a valid HTTP(S) URL", u),
+				Fatal:   true,
+			})
+		}
+	}
+
+	// Check if the event kinds are valid
+	events := make([]types.WebhookEventType, 0, len(w.Events))
+	for _, e := range w.Events {
+		if e != types.WebhookSong && e != types.WebhookLine && e != types.WebhookLyricsState {
+			errs = append(errs, ValidationError{
+				Path:    path + "/events",
+				Message: fmt.Sprintf("'%s' is not a valid value. Allowed values are 'song', 'line' and 'lyrics-state'. It will be ignored", e),
+				Fatal:   false,
+			})
+			continue
+		}
+		events = append(events, e)
+	}
+	w.Events = events
+
+	// Check if the batch time is negative
+	if w.Batch < 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/batch",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0s)", w.Batch),
+			Fatal:   false,
+		})
+		w.Batch = 0
+	}
+
+	// Check if the timeout is set to <=0s
+	if w.Timeout <= 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/timeout",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the default instead (5s)", w.Timeout),
+			Fatal:   false,
+		})
+		w.Timeout = 5
+	}
+
+	// Check if the retry delay is negative
+	if w.RetryDelay < 0 {
+		errs = append(errs, ValidationError{
+			Path:    path + "/retry-delay",
+			Message: fmt.Sprintf("'%f' is not a valid value. Using the possible minimum instead (0s)", w.RetryDelay),
+			Fatal:   false,
+		})
+		w.RetryDelay = 0
+	}
+
+	return
+}
+
+func isOutputType(t types.OutputType) bool {
+	return t == types.OutputPiped || t == types.OutputDBus || t == types.OutputTUI ||
+		t == types.OutputHTTP || t == types.OutputSocket || t == types.OutputNotify ||
+		t == types.OutputMPV || t == types.OutputWebhook
+}
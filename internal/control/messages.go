@@ -0,0 +1,111 @@
+package control
+
+import (
+	"lrcsnc/internal/drift"
+	"lrcsnc/internal/practice"
+)
+
+type CommandType uint8
+
+const (
+	// CommandReload rereads the config file
+	CommandReload CommandType = iota
+	// CommandRefetch fetches the current song's lyrics again, ignoring the cache
+	CommandRefetch
+	// CommandSetOffset sets the effective timestamp offset (Data is float64, in seconds)
+	CommandSetOffset
+	// CommandAdjustOffset shifts the effective timestamp offset (Data is float64, in seconds)
+	CommandAdjustOffset
+	// CommandResetOffset drops any runtime offset adjustments, reverting to the config's offset
+	CommandResetOffset
+	// CommandDropCache removes the current song's lyrics from the cache
+	CommandDropCache
+	// CommandPlayer controls the active player (Data is PlayerAction)
+	CommandPlayer
+	// CommandStatus returns the current state as Status
+	CommandStatus
+	// CommandQuit shuts the app down
+	CommandQuit
+	// CommandSeekLine seeks the player to the lyric line with the index (Data is int)
+	CommandSeekLine
+	// CommandSeekRelativeLine seeks the player to the non-empty lyric line
+	// that is N lines away from the current one (Data is int: -1 for previous, 1 for next, etc.)
+	CommandSeekRelativeLine
+	// CommandSeekPhrase seeks the player to the first lyric line containing the phrase (Data is string)
+	CommandSeekPhrase
+	// CommandStartLoop starts looping a range of lyric lines (Data is practice.Loop)
+	CommandStartLoop
+	// CommandStopLoop stops the active practice loop
+	CommandStopLoop
+	// CommandSetSongOffset sets the current song's own timestamp offset (Data is float64)
+	CommandSetSongOffset
+	// CommandAdjustSongOffset shifts the current song's own timestamp offset (Data is float64)
+	CommandAdjustSongOffset
+	// CommandDrift returns the drift statistics of every player as map[string]drift.Stats
+	CommandDrift
+	// CommandSeek seeks the player to the position (Data is float64, in seconds)
+	CommandSeek
+	// CommandSeekRelative seeks the player by the amount (Data is float64, in seconds; negative is backwards)
+	CommandSeekRelative
+)
+
+// PlayerAction is an action performed on the active player by CommandPlayer
+type PlayerAction string
+
+const (
+	PlayerNext      PlayerAction = "next"
+	PlayerPrevious  PlayerAction = "previous"
+	PlayerPlayPause PlayerAction = "play-pause"
+)
+
+// Command is a request to change something in a running instance.
+// Commands can come from any control surface (D-Bus, the control socket, signals, etc.)
+// and are handled by the sync module.
+type Command struct {
+	Type CommandType
+	Data any
+	// Result receives the outcome of the command once it's handled.
+	// May be nil if the sender doesn't care about it.
+	Result chan Result
+}
+
+// Result is the outcome of a handled command.
+// Data is only set by the commands that return something (e.g. CommandStatus).
+type Result struct {
+	Data any
+	Err  error
+}
+
+// Status is a snapshot of the app's state returned by CommandStatus.
+type Status struct {
+	Player         string   `json:"player"`
+	PlaybackStatus string   `json:"playback-status"`
+	Title          string   `json:"title"`
+	Artists        []string `json:"artists"`
+	Album          string   `json:"album"`
+	Position       float64  `json:"position"`
+	Duration       float64  `json:"duration"`
+	LyricsState    string   `json:"lyrics-state"`
+	LineIndex      int      `json:"line-index"`
+	Line           string   `json:"line"`
+	Offset         float64  `json:"offset"`
+	// OffsetProfile is the player and audio sink combination
+	// the runtime offset adjustments are kept for
+	OffsetProfile string `json:"offset-profile"`
+	// SongOffset is the current song's own timestamp offset stacked on top of Offset
+	SongOffset float64 `json:"song-offset"`
+	// Loop is the active practice loop (if any)
+	Loop *practice.Loop `json:"loop,omitempty"`
+	// Drift is the drift statistics of the player (if it was checked at all)
+	Drift *drift.Stats `json:"drift,omitempty"`
+}
+
+var CommandChannel = make(chan Command)
+
+// Send sends a command to CommandChannel and waits for it to be handled.
+func Send(t CommandType, data any) (any, error) {
+	result := make(chan Result, 1)
+	CommandChannel <- Command{Type: t, Data: data, Result: result}
+	r := <-result
+	return r.Data, r.Err
+}
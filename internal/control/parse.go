@@ -0,0 +1,149 @@
+package control
+
+import (
+	"strconv"
+	"strings"
+
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/practice"
+)
+
+// Usage describes the commands accepted by Parse
+const Usage = `Available commands:
+  status                      show the current state
+  reload                      reread the config file
+  refetch                     fetch the current song's lyrics again, ignoring the cache
+  offset <value>              set the timestamp offset in seconds (e.g. 0.5)
+  offset <+value|-value>      shift the timestamp offset by the value (e.g. +0.2)
+  offset reset                revert the timestamp offset to the config's one
+  offset song <value>         set, shift (with +/-) or reset the current song's own offset
+  cache drop-current          remove the current song's lyrics from the cache
+  player <action>             control the active player (next, previous, play-pause)
+  line <prev|next>            seek to the previous or the next lyric line
+  line <index>                seek to the lyric line with the index (as shown by status)
+  find <phrase>               seek to the first lyric line containing the phrase
+  seek <seconds>              seek to the position, or by the amount with +/- (e.g. -5)
+  loop <start> <end> [rate]   loop the range of lyric lines, optionally at another playback rate
+  loop off                    stop looping
+  drift                       show how much the players' positions drifted
+  quit                        shut the running instance down`
+
+// Parse converts the textual form of a command (as used by the control socket
+// and `lrcsnc ctl`) into a command type and its data.
+func Parse(args []string) (CommandType, any, error) {
+	if len(args) == 0 {
+		return 0, nil, errs.ErrUnknownCommand
+	}
+
+	switch args[0] {
+	case "status":
+		return withoutArgs(args, CommandStatus)
+	case "reload":
+		return withoutArgs(args, CommandReload)
+	case "refetch":
+		return withoutArgs(args, CommandRefetch)
+	case "drift":
+		return withoutArgs(args, CommandDrift)
+	case "quit":
+		return withoutArgs(args, CommandQuit)
+	case "offset":
+		if len(args) == 3 && args[1] == "song" {
+			if args[2] == "reset" {
+				return CommandSetSongOffset, 0.0, nil
+			}
+			return parseSigned(args[2], CommandSetSongOffset, CommandAdjustSongOffset)
+		}
+		if len(args) != 2 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		if args[1] == "reset" {
+			return CommandResetOffset, nil, nil
+		}
+		return parseSigned(args[1], CommandSetOffset, CommandAdjustOffset)
+	case "cache":
+		if len(args) != 2 || args[1] != "drop-current" {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		return CommandDropCache, nil, nil
+	case "player":
+		if len(args) != 2 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		switch action := PlayerAction(args[1]); action {
+		case PlayerNext, PlayerPrevious, PlayerPlayPause:
+			return CommandPlayer, action, nil
+		default:
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+	case "line":
+		if len(args) != 2 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		switch args[1] {
+		case "prev":
+			return CommandSeekRelativeLine, -1, nil
+		case "next":
+			return CommandSeekRelativeLine, 1, nil
+		}
+		i, err := strconv.Atoi(args[1])
+		if err != nil || i < 0 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		return CommandSeekLine, i, nil
+	case "seek":
+		if len(args) != 2 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		return parseSigned(args[1], CommandSeek, CommandSeekRelative)
+	case "find":
+		phrase := strings.TrimSpace(strings.Join(args[1:], " "))
+		if phrase == "" {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		return CommandSeekPhrase, phrase, nil
+	case "loop":
+		if len(args) == 2 && args[1] == "off" {
+			return CommandStopLoop, nil, nil
+		}
+		if len(args) != 3 && len(args) != 4 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		start, err := strconv.Atoi(args[1])
+		if err != nil || start < 0 {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		end, err := strconv.Atoi(args[2])
+		if err != nil || end < start {
+			return 0, nil, errs.ErrInvalidCommandData
+		}
+		l := practice.Loop{Start: start, End: end}
+		if len(args) == 4 {
+			l.Rate, err = strconv.ParseFloat(args[3], 64)
+			if err != nil || l.Rate <= 0 {
+				return 0, nil, errs.ErrInvalidCommandData
+			}
+		}
+		return CommandStartLoop, l, nil
+	default:
+		return 0, nil, errs.ErrUnknownCommand
+	}
+}
+
+// parseOffset parses an offset value: a signed one adjusts the offset, an unsigned one sets it
+func parseSigned(arg string, set CommandType, adjust CommandType) (CommandType, any, error) {
+	v, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return 0, nil, errs.ErrInvalidCommandData
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		return adjust, v, nil
+	}
+	return set, v, nil
+}
+
+func withoutArgs(args []string, t CommandType) (CommandType, any, error) {
+	if len(args) != 1 {
+		return 0, nil, errs.ErrInvalidCommandData
+	}
+	return t, nil, nil
+}
@@ -0,0 +1,166 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/log"
+)
+
+// The control protocol is as simple as it gets:
+// the client sends one JSON array of arguments (e.g. ["offset", "+0.2"]) terminated by a newline,
+// the server answers with one Response object terminated by a newline and closes the connection.
+
+// Response is the answer of the control socket to a request.
+type Response struct {
+	OK    bool            `json:"ok"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+const requestTimeout = 5 * time.Second
+
+var listener net.Listener
+var connections sync.WaitGroup
+
+// ExpandSocketPath expands the environment variables in the socket path.
+// If $XDG_RUNTIME_DIR is not set, the temp directory is used instead.
+func ExpandSocketPath(p string) string {
+	return os.Expand(p, func(key string) string {
+		v := os.Getenv(key)
+		if key == "XDG_RUNTIME_DIR" && v == "" {
+			return os.TempDir()
+		}
+		return v
+	})
+}
+
+// Listen starts serving the control socket on the provided path.
+func Listen(p string) error {
+	p = ExpandSocketPath(p)
+
+	// A socket file may be left over from an instance that didn't exit properly,
+	// but it may also belong to an instance that is still running
+	if _, err := os.Stat(p); err == nil {
+		if c, err := net.DialTimeout("unix", p, time.Second); err == nil {
+			c.Close()
+			log.Error("control/Listen", "The control socket '"+p+"' is served by another instance")
+			return errs.ErrSocketInUse
+		}
+		log.Debug("control/Listen", "Removing a stale control socket")
+		os.Remove(p)
+	}
+
+	l, err := net.Listen("unix", p)
+	if err != nil {
+		log.Error("control/Listen", "Failed to listen on the control socket: "+err.Error())
+		return err
+	}
+	if err := os.Chmod(p, 0o600); err != nil {
+		log.Warn("control/Listen", "Failed to restrict the control socket permissions: "+err.Error())
+	}
+	listener = l
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				// The listener was closed
+				return
+			}
+			connections.Add(1)
+			go handleConnection(c)
+		}
+	}()
+
+	log.Info("control/Listen", "Listening for control commands on '"+p+"'")
+
+	return nil
+}
+
+// Close stops serving the control socket.
+// It waits for the requests in progress to be answered.
+func Close() {
+	if listener == nil {
+		return
+	}
+
+	listener.Close()
+	connections.Wait()
+	listener = nil
+}
+
+func handleConnection(c net.Conn) {
+	defer connections.Done()
+	defer c.Close()
+
+	c.SetDeadline(time.Now().Add(requestTimeout))
+
+	line, err := bufio.NewReader(c).ReadBytes('\n')
+	if err != nil {
+		log.Debug("control/handleConnection", "Failed to read the request: "+err.Error())
+		return
+	}
+
+	var args []string
+	var resp Response
+	if err := json.Unmarshal(line, &args); err != nil {
+		resp.Error = errs.ErrUnmarshalFail.Error()
+	} else if t, data, err := Parse(args); err != nil {
+		resp.Error = err.Error()
+	} else if out, err := Send(t, data); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.OK = true
+		if out != nil {
+			resp.Data, err = json.Marshal(out)
+			if err != nil {
+				log.Error("control/handleConnection", "Failed to marshal the response data: "+err.Error())
+			}
+		}
+	}
+
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		log.Error("control/handleConnection", "Failed to marshal the response: "+err.Error())
+		return
+	}
+	c.Write(append(encoded, '\n'))
+}
+
+// Request sends the command arguments to the control socket on the provided path
+// and returns the response.
+func Request(p string, args []string) (Response, error) {
+	var resp Response
+
+	c, err := net.DialTimeout("unix", ExpandSocketPath(p), time.Second)
+	if err != nil {
+		return resp, err
+	}
+	defer c.Close()
+
+	c.SetDeadline(time.Now().Add(requestTimeout))
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return resp, errs.ErrMarshalFail
+	}
+	if _, err := c.Write(append(encoded, '\n')); err != nil {
+		return resp, err
+	}
+
+	line, err := bufio.NewReader(c).ReadBytes('\n')
+	if err != nil {
+		return resp, err
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return resp, errs.ErrUnmarshalFail
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,64 @@
+package drift
+
+import (
+	"math"
+	"sync"
+)
+
+// Stats describes how much the position of a player drifted away
+// from the extrapolated one over the session.
+type Stats struct {
+	// Checks is how many times the position was checked
+	Checks int `json:"checks"`
+	// Corrections is how many times the drift was over the threshold and was corrected
+	Corrections int `json:"corrections"`
+	// Last is the drift found by the last check in seconds
+	// (positive means the player is ahead of the extrapolated position)
+	Last float64 `json:"last"`
+	// Max is the largest absolute drift found in seconds
+	Max float64 `json:"max"`
+	// Mean is the mean absolute drift in seconds
+	Mean float64 `json:"mean"`
+}
+
+var state = struct {
+	M     sync.Mutex
+	Stats map[string]Stats
+}{Stats: make(map[string]Stats)}
+
+// Record adds the result of a drift check for the player.
+func Record(player string, drift float64, corrected bool) {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	s := state.Stats[player]
+	s.Mean = (s.Mean*float64(s.Checks) + math.Abs(drift)) / float64(s.Checks+1)
+	s.Checks++
+	if corrected {
+		s.Corrections++
+	}
+	s.Last = drift
+	s.Max = max(s.Max, math.Abs(drift))
+	state.Stats[player] = s
+}
+
+// Get returns the drift statistics of the player (if it was checked at all).
+func Get(player string) (Stats, bool) {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	s, ok := state.Stats[player]
+	return s, ok
+}
+
+// All returns the drift statistics of every player checked during the session.
+func All() map[string]Stats {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	all := make(map[string]Stats, len(state.Stats))
+	for p, s := range state.Stats {
+		all[p] = s
+	}
+	return all
+}
@@ -18,23 +18,38 @@ import (
 // If the lyrics are not found in the cache, it fetches the lyrics from the configured lyrics provider.
 // If the lyrics are successfully retrieved and caching is enabled, it stores the lyrics in the cache.
 func Fetch() (structs.LyricsData, error) {
+	return fetch(true)
+}
+
+// Refetch works the same way as Fetch, except it always goes online
+// (the received lyrics still get stored in the cache if it's enabled).
+func Refetch() (structs.LyricsData, error) {
+	return fetch(false)
+}
+
+func fetch(useCache bool) (structs.LyricsData, error) {
 	global.Player.M.Lock()
 	song := global.Player.P.Song
 	global.Player.M.Unlock()
 
 	log.Debug("lyrics/fetch", fmt.Sprintf("Fetching lyrics for song %v - %v", strings.Join(song.Artists, ", "), song.Title))
 
+	// The song's own offset is kept even if the lyrics themselves are fetched again
+	var songOffset float64
+
 	// yea i'm not covering this with mutexes good luck timing this out
 	if global.Config.C.Cache.Enabled {
 		cachedData, cacheState := cache.Fetch(&song)
-		if cacheState == cache.CacheStateActive {
+		if useCache && cacheState == cache.CacheStateActive {
 			return cachedData, nil
 		}
+		songOffset = cachedData.Offset
 	}
 
 	log.Debug("lyrics/fetch", fmt.Sprintf("Moving online; using %v", global.Config.C.Lyrics.Provider))
 
 	res, err := providers.Providers[global.Config.C.Lyrics.Provider].Get(song)
+	res.Offset = songOffset
 	if err != nil {
 		if errors.Is(err, errs.ErrLyricsNotFound) {
 			log.Debug("lyrics/fetch", "The lyrics, unfortunately, were not found")
@@ -51,10 +51,15 @@ func makeURL(song structs.Song, t lrcLibURLType) (out *url.URL) {
 
 func sendRequest(link *url.URL) ([]byte, error) {
 	resp, err := http.Get((*link).String())
+	if err != nil {
+		return nil, errors.ErrLyricsServerError
+	}
 	if resp.StatusCode == 404 {
+		resp.Body.Close()
 		return nil, errors.ErrLyricsNotFound
 	}
-	if err != nil || resp.StatusCode != 200 {
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
 		return nil, errors.ErrLyricsServerError
 	}
 
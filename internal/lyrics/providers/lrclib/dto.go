@@ -115,15 +115,19 @@ func parseSyncedLyrics(lyrics string) (out []structs.Lyric) {
 		}
 	}
 
-	if hasRepetitiveLyrics {
-		slices.SortFunc(out, func(i, j structs.Lyric) int {
-			return cmp.Compare(i.Time, j.Time)
-		})
+	// Repetitive lyrics are out of order for sure, but the sync engine
+	// relies on the lyrics being sorted by time either way
+	if hasRepetitiveLyrics || !slices.IsSortedFunc(out, compareLyricTimes) {
+		slices.SortStableFunc(out, compareLyricTimes)
 	}
 
 	return
 }
 
+func compareLyricTimes(i, j structs.Lyric) int {
+	return cmp.Compare(i.Time, j.Time)
+}
+
 // A simple sanitize requires trimming any carriage return and space symbols
 // It is wrapped into a function to be simple to update if needed
 func sanitizeLyric(lyric string) string {
@@ -75,8 +75,11 @@ func Connect() error {
 
 // Disconnect disconnects from D-Bus. Any error is counted as fatal.
 func Disconnect() {
-	// We can close the connection since the method
-	// also will close all the channels used for signals
+	// The signal channels are removed first, so closing the connection
+	// doesn't close them while a signal is still being delivered
+	conn.RemoveSignal(playerSignalReceiver)
+	conn.RemoveSignal(nameOwnerChangedSignalReceiver)
+
 	err := conn.Close()
 	if err != nil {
 		log.Fatal("mpris/Disconnect", err.Error())
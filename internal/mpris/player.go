@@ -4,6 +4,7 @@ import (
 	"slices"
 	"strings"
 
+	errs "lrcsnc/internal/pkg/errors"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
 	"lrcsnc/internal/pkg/structs"
@@ -210,6 +211,8 @@ func ApplyMetadataOntoGlobal(md mpris.Metadata) (err error) {
 		return err
 	}
 	global.Player.P.Song.Duration = float64(dur) / 1000 / 1000
+	// The album art is optional
+	global.Player.P.Song.ArtURL, _ = md.ArtURL()
 	global.Player.P.Song.LyricsData.LyricsState = types.LyricsStateLoading
 
 	return nil
@@ -247,6 +250,30 @@ func GetRate() (float64, error) {
 	return player.GetRate()
 }
 
+// SetRate sets new playback rate for the player.
+// Returns ErrRateUnsupported if the player doesn't allow the rate.
+func SetRate(rate float64) error {
+	if player == nil {
+		return nil
+	}
+
+	// MinimumRate and MaximumRate are optional for players; if they are missing,
+	// only the normal rate is allowed
+	minRate, err := player.GetMinimumRate()
+	if err != nil {
+		minRate = 1
+	}
+	maxRate, err := player.GetMaximumRate()
+	if err != nil {
+		maxRate = 1
+	}
+	if rate < minRate || rate > maxRate {
+		return errs.ErrRateUnsupported
+	}
+
+	return conn.Object(player.GetName(), "/org/mpris/MediaPlayer2").SetProperty(mpris.PlayerInterface+".Rate", dbus.MakeVariant(rate))
+}
+
 // GetMetadata returns current metadata
 func GetMetadata() (mpris.Metadata, error) {
 	if player == nil {
@@ -264,3 +291,30 @@ func SetPosition(pos float64) error {
 
 	return player.SetPosition(int64(pos * 1000 * 1000))
 }
+
+// Next skips to the next track
+func Next() error {
+	if player == nil {
+		return nil
+	}
+
+	return player.Next()
+}
+
+// Previous skips to the previous track
+func Previous() error {
+	if player == nil {
+		return nil
+	}
+
+	return player.Previous()
+}
+
+// PlayPause toggles the playback
+func PlayPause() error {
+	if player == nil {
+		return nil
+	}
+
+	return player.PlayPause()
+}
@@ -0,0 +1,137 @@
+package offset
+
+import (
+	"strings"
+	"sync"
+
+	"lrcsnc/internal/pkg/global"
+)
+
+// current is the player and the audio sink that choose the offset profiles
+// and the runtime adjustment in use
+var current = struct {
+	M      sync.Mutex
+	Player string
+	Sink   string
+}{}
+
+// adjustments are the offset adjustments made while the app is running
+// (e.g. through D-Bus), one per player and audio sink combination.
+// They are stacked on top of the config's timestamp offset and profiles,
+// are not affected by config reloads and persist in the state file.
+var adjustments = struct {
+	M    sync.Mutex
+	V    map[string]float64
+	Path string
+}{V: make(map[string]float64)}
+
+// Get returns the effective timestamp offset in seconds.
+//
+// It locks the config mutex, so it must not be called while holding it.
+func Get() float64 {
+	key := Profile()
+	configOffset := configured()
+
+	adjustments.M.Lock()
+	defer adjustments.M.Unlock()
+
+	return configOffset + adjustments.V[key]
+}
+
+// Set adjusts the runtime offset so that the effective timestamp offset becomes v.
+//
+// It locks the config mutex, so it must not be called while holding it.
+func Set(v float64) {
+	key := Profile()
+	configOffset := configured()
+
+	adjustments.M.Lock()
+	defer adjustments.M.Unlock()
+
+	adjustments.V[key] = v - configOffset
+	save()
+}
+
+// Adjust shifts the effective timestamp offset by d.
+func Adjust(d float64) {
+	key := Profile()
+
+	adjustments.M.Lock()
+	defer adjustments.M.Unlock()
+
+	adjustments.V[key] += d
+	save()
+}
+
+// Reset drops the runtime offset adjustments of the current player and audio sink,
+// so that the effective timestamp offset becomes the configured one.
+func Reset() {
+	key := Profile()
+
+	adjustments.M.Lock()
+	defer adjustments.M.Unlock()
+
+	delete(adjustments.V, key)
+	save()
+}
+
+// SetPlayer sets the player whose offset profile is used.
+func SetPlayer(player string) {
+	current.M.Lock()
+	defer current.M.Unlock()
+
+	current.Player = strings.ToLower(player)
+}
+
+// SetSink sets the audio sink whose offset profile is used.
+func SetSink(sink string) {
+	current.M.Lock()
+	defer current.M.Unlock()
+
+	current.Sink = sink
+}
+
+// Profile returns the name of the current player and audio sink combination
+// that the runtime adjustments are kept for (e.g. "spotify@bluez_output.00_11_22_33_44_55.1").
+func Profile() string {
+	current.M.Lock()
+	defer current.M.Unlock()
+
+	if current.Sink == "" {
+		return current.Player
+	}
+	return current.Player + "@" + current.Sink
+}
+
+// configured returns the config's timestamp offset
+// with the profiles of the current player and audio sink stacked on top
+func configured() float64 {
+	current.M.Lock()
+	player, sink := current.Player, current.Sink
+	current.M.Unlock()
+
+	global.Config.M.Lock()
+	defer global.Config.M.Unlock()
+
+	profiles := global.Config.C.Lyrics.OffsetProfiles
+	return global.Config.C.Lyrics.TimestampOffset +
+		matchProfile(profiles.Players, player) +
+		matchProfile(profiles.Sinks, sink)
+}
+
+// matchProfile returns the offset of the profile whose name is found in the identity
+// (case-insensitively). If there are several, the longest name wins as the most specific one.
+func matchProfile(profiles map[string]float64, identity string) float64 {
+	if identity == "" {
+		return 0
+	}
+
+	identity = strings.ToLower(identity)
+	match, offset := "", 0.0
+	for name, v := range profiles {
+		if len(name) > len(match) && strings.Contains(identity, strings.ToLower(name)) {
+			match, offset = name, v
+		}
+	}
+	return offset
+}
@@ -0,0 +1,86 @@
+package offset
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/log"
+)
+
+// Load reads the runtime adjustments saved in the state file
+// and keeps saving them there on every change from now on.
+// An empty path means the adjustments are not saved at all.
+func Load(p string) error {
+	adjustments.M.Lock()
+	defer adjustments.M.Unlock()
+
+	adjustments.Path = os.ExpandEnv(p)
+	if adjustments.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(adjustments.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		log.Error("offset/Load", "Couldn't read the offset state file: "+err.Error())
+		return errs.ErrFileUnreadable
+	}
+
+	v := make(map[string]float64)
+	if err := json.Unmarshal(data, &v); err != nil {
+		log.Error("offset/Load", "Couldn't parse the offset state file: "+err.Error())
+		return errs.ErrUnmarshalFail
+	}
+	adjustments.V = v
+
+	log.Debug("offset/Load", "Loaded the offset adjustments from "+adjustments.Path)
+	return nil
+}
+
+// SetStateFile switches to another state file (e.g. after a config reload).
+// The adjustments are read from the new file if it exists; otherwise the current ones
+// are kept and saved there on the next change. Nothing is done if the path is the same.
+func SetStateFile(p string) error {
+	adjustments.M.Lock()
+	same := adjustments.Path == os.ExpandEnv(p)
+	adjustments.M.Unlock()
+
+	if same {
+		return nil
+	}
+	return Load(p)
+}
+
+// save writes the runtime adjustments to the state file (if there is one).
+// The file is replaced atomically, so it's never left half-written.
+//
+// It does NOT lock the adjustments mutex.
+func save() {
+	if adjustments.Path == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(adjustments.V, "", "  ")
+	if err != nil {
+		log.Error("offset/save", "Couldn't marshal the offset adjustments: "+err.Error())
+		return
+	}
+
+	if err := os.MkdirAll(path.Dir(adjustments.Path), 0755); err != nil {
+		log.Error("offset/save", "Couldn't create the offset state file's directory: "+err.Error())
+		return
+	}
+
+	tmp := adjustments.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Error("offset/save", "Couldn't write the offset state file: "+err.Error())
+		return
+	}
+	if err := os.Rename(tmp, adjustments.Path); err != nil {
+		log.Error("offset/save", "Couldn't replace the offset state file: "+err.Error())
+	}
+}
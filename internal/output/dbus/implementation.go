@@ -0,0 +1,112 @@
+package dbus
+
+import (
+	"reflect"
+	"sync"
+
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+)
+
+// state keeps the last values sent to D-Bus
+// to not spam the bus with the same signals.
+// Its lock also guards the connection and the properties, which are replaced on restarts
+var state = struct {
+	M         sync.Mutex
+	LineIndex int
+	LineText  string
+	SongID    uint64
+}{LineIndex: -1}
+
+type Controller struct{}
+
+func (Controller) OnConfigUpdate() {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	if props == nil {
+		return
+	}
+
+	setProperty("Offset", offset.Get())
+}
+
+func (Controller) OnPlayerUpdate() {
+	global.Player.M.Lock()
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	if props == nil {
+		return
+	}
+
+	lyrics := make([]lyricLine, len(song.LyricsData.Lyrics))
+	for i, l := range song.LyricsData.Lyrics {
+		lyrics[i] = lyricLine{Time: l.Time, Text: l.Text}
+	}
+
+	setProperty("LyricsState", song.LyricsData.LyricsState.String())
+	setProperty("Lyrics", lyrics)
+
+	if id := song.ID(); id != state.SongID {
+		state.SongID = id
+		err := conn.Emit(objectPath, iface+".SongChanged", song.Title, song.Artists, song.Album)
+		if err != nil {
+			log.Error("output/dbus/OnPlayerUpdate", "Failed to emit SongChanged: "+err.Error())
+		}
+	}
+}
+
+func (Controller) OnOverwrite(overwrite string) {}
+
+func (Controller) OnPositionUpdate() {}
+
+func (Controller) DisplayLyric(lyricIndex int) {
+	global.Player.M.Lock()
+	lyricsData := global.Player.P.Song.LyricsData
+	global.Player.M.Unlock()
+
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	if props == nil {
+		return
+	}
+
+	text := ""
+	if lyricIndex >= 0 && lyricIndex < len(lyricsData.Lyrics) {
+		text = lyricsData.Lyrics[lyricIndex].Text
+	} else {
+		lyricIndex = -1
+	}
+
+	setProperty("LyricsState", lyricsData.LyricsState.String())
+
+	if lyricIndex == state.LineIndex && text == state.LineText {
+		return
+	}
+	state.LineIndex = lyricIndex
+	state.LineText = text
+
+	setProperty("CurrentLine", text)
+	setProperty("LineIndex", int32(lyricIndex))
+
+	err := conn.Emit(objectPath, iface+".LineChanged", int32(lyricIndex), text)
+	if err != nil {
+		log.Error("output/dbus/DisplayLyric", "Failed to emit LineChanged: "+err.Error())
+	}
+}
+
+// setProperty updates the property only if the value differs,
+// since every update emits a PropertiesChanged signal.
+// Must be called while holding the state lock.
+func setProperty(name string, v any) {
+	if reflect.DeepEqual(props.GetMust(iface, name), v) {
+		return
+	}
+	props.SetMust(iface, name, v)
+}
@@ -0,0 +1,74 @@
+package dbus
+
+import (
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/practice"
+
+	dbuslib "github.com/godbus/dbus/v5"
+)
+
+// service holds the methods exported under the org.lrcsnc.Lyrics interface.
+// Every method is just a proxy to the corresponding control command.
+type service struct{}
+
+// Reload rereads the config file
+func (service) Reload() *dbuslib.Error {
+	_, err := control.Send(control.CommandReload, nil)
+	return toDBusError(err)
+}
+
+// SetOffset sets the effective timestamp offset in seconds
+func (service) SetOffset(offset float64) *dbuslib.Error {
+	_, err := control.Send(control.CommandSetOffset, offset)
+	return toDBusError(err)
+}
+
+// Refetch fetches the current song's lyrics again, ignoring the cache
+func (service) Refetch() *dbuslib.Error {
+	_, err := control.Send(control.CommandRefetch, nil)
+	return toDBusError(err)
+}
+
+// PreviousLine seeks the player to the previous non-empty lyric line
+func (service) PreviousLine() *dbuslib.Error {
+	_, err := control.Send(control.CommandSeekRelativeLine, -1)
+	return toDBusError(err)
+}
+
+// NextLine seeks the player to the next non-empty lyric line
+func (service) NextLine() *dbuslib.Error {
+	_, err := control.Send(control.CommandSeekRelativeLine, 1)
+	return toDBusError(err)
+}
+
+// GoToLine seeks the player to the lyric line with the index
+func (service) GoToLine(index int32) *dbuslib.Error {
+	_, err := control.Send(control.CommandSeekLine, int(index))
+	return toDBusError(err)
+}
+
+// FindLine seeks the player to the first lyric line containing the phrase
+func (service) FindLine(phrase string) *dbuslib.Error {
+	_, err := control.Send(control.CommandSeekPhrase, phrase)
+	return toDBusError(err)
+}
+
+// SetLoop starts looping the range of lyric lines.
+// If the rate is not 0, the playback rate is changed while looping.
+func (service) SetLoop(start int32, end int32, rate float64) *dbuslib.Error {
+	_, err := control.Send(control.CommandStartLoop, practice.Loop{Start: int(start), End: int(end), Rate: rate})
+	return toDBusError(err)
+}
+
+// ClearLoop stops looping
+func (service) ClearLoop() *dbuslib.Error {
+	_, err := control.Send(control.CommandStopLoop, nil)
+	return toDBusError(err)
+}
+
+func toDBusError(err error) *dbuslib.Error {
+	if err == nil {
+		return nil
+	}
+	return dbuslib.MakeFailedError(err)
+}
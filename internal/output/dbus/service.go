@@ -0,0 +1,155 @@
+package dbus
+
+import (
+	"lrcsnc/internal/offset"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/types"
+
+	dbuslib "github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	serviceName = "org.lrcsnc.Lyrics"
+	objectPath  = dbuslib.ObjectPath("/org/lrcsnc/Lyrics")
+	iface       = "org.lrcsnc.Lyrics"
+)
+
+var conn *dbuslib.Conn
+var props *prop.Properties
+
+// lyricLine is the D-Bus representation of a lyric: a (ds) struct
+type lyricLine struct {
+	Time float64
+	Text string
+}
+
+// Init connects to the session bus, exports the lyrics object and
+// requests the service name. Any error means the service is unavailable.
+func Init() error {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	// A restarted service starts anew, so that it publishes the current line and song again
+	state.LineIndex = -1
+	state.LineText = ""
+	state.SongID = 0
+
+	var err error
+
+	// The connection is private and separate from the MPRIS one,
+	// so that the signals of one don't mess with the other
+	conn, err = dbuslib.SessionBusPrivate()
+	if err != nil {
+		log.Error("output/dbus/Init", err.Error())
+		return err
+	}
+	if err = conn.Auth(nil); err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", err.Error())
+		return err
+	}
+	if err = conn.Hello(); err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", err.Error())
+		return err
+	}
+	log.Debug("output/dbus/Init", "Got a private connection from D-Bus")
+
+	if err = conn.Export(service{}, objectPath, iface); err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", "Failed to export the methods: "+err.Error())
+		return err
+	}
+
+	props, err = prop.Export(conn, objectPath, prop.Map{
+		iface: {
+			"CurrentLine": {Value: "", Emit: prop.EmitTrue},
+			"LineIndex":   {Value: int32(-1), Emit: prop.EmitTrue},
+			"LyricsState": {Value: types.LyricsStateUnknown.String(), Emit: prop.EmitTrue},
+			"Lyrics":      {Value: []lyricLine{}, Emit: prop.EmitTrue},
+			"Offset":      {Value: offset.Get(), Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", "Failed to export the properties: "+err.Error())
+		return err
+	}
+
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       iface,
+				Methods:    introspect.Methods(service{}),
+				Properties: props.Introspection(iface),
+				Signals: []introspect.Signal{
+					{
+						Name: "LineChanged",
+						Args: []introspect.Arg{
+							{Name: "index", Type: "i"},
+							{Name: "text", Type: "s"},
+						},
+					},
+					{
+						Name: "SongChanged",
+						Args: []introspect.Arg{
+							{Name: "title", Type: "s"},
+							{Name: "artists", Type: "as"},
+							{Name: "album", Type: "s"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err = conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", "Failed to export the introspection data: "+err.Error())
+		return err
+	}
+
+	reply, err := conn.RequestName(serviceName, dbuslib.NameFlagDoNotQueue)
+	if err != nil {
+		closeConn()
+		log.Error("output/dbus/Init", "Failed to request the service name: "+err.Error())
+		return err
+	}
+	if reply != dbuslib.RequestNameReplyPrimaryOwner {
+		closeConn()
+		log.Error("output/dbus/Init", "The name '"+serviceName+"' is already taken. Is there another instance running?")
+		return errs.ErrDBusNameTaken
+	}
+
+	log.Info("output/dbus/Init", "Registered '"+serviceName+"' on the session bus")
+
+	return nil
+}
+
+// Close releases the service name and closes the connection.
+func Close() {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	closeConn()
+}
+
+// closeConn closes the connection and forgets it along with the properties,
+// so that the controller does nothing until the service is started again.
+// Must be called while holding the state lock.
+func closeConn() {
+	if conn == nil {
+		return
+	}
+
+	if err := conn.Close(); err != nil {
+		log.Error("output/dbus/Close", err.Error())
+	}
+	conn = nil
+	props = nil
+}
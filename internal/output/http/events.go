@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// keepAliveInterval is how often an idle stream gets a comment,
+// so that it's not dropped by something in between
+const keepAliveInterval = 30 * time.Second
+
+// event is a named Server-Sent Event with JSON data
+type event struct {
+	Name string
+	Data []byte
+}
+
+// Snapshot is the current state as it's served to the overlay
+type Snapshot struct {
+	Player      string   `json:"player"`
+	Status      string   `json:"status"`
+	Title       string   `json:"title"`
+	Artists     []string `json:"artists"`
+	Album       string   `json:"album"`
+	Position    float64  `json:"position"`
+	Duration    float64  `json:"duration"`
+	Rate        float64  `json:"rate"`
+	LyricsState string   `json:"lyrics_state"`
+	Index       int      `json:"index"`
+	Line        string   `json:"line"`
+	Previous    []string `json:"previous"`
+	Next        []string `json:"next"`
+}
+
+// Overwrite is a message shown in place of the lyrics for a while
+type Overwrite struct {
+	Text string `json:"text"`
+}
+
+// snapshot returns the current state
+func (o *Output) snapshot() Snapshot {
+	index := int(o.lyricIndex.Load())
+
+	global.Config.M.Lock()
+	context := o.config().Context
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	defer global.Player.M.Unlock()
+
+	p := global.Player.P
+	lyrics := p.Song.LyricsData.Lyrics
+	before, after := p.Song.LyricsData.Surrounding(index, int(context.Previous), int(context.Next))
+
+	line := ""
+	if index >= 0 && index < len(lyrics) {
+		line = lyrics[index].Text
+	}
+	status := string(p.PlaybackStatus)
+	if status == "" {
+		status = string(mprislib.PlaybackStopped)
+	}
+	artists := p.Song.Artists
+	if artists == nil {
+		artists = []string{}
+	}
+
+	return Snapshot{
+		Player:      p.Name,
+		Status:      status,
+		Title:       p.Song.Title,
+		Artists:     artists,
+		Album:       p.Song.Album,
+		Position:    p.Position,
+		Duration:    p.Song.Duration,
+		Rate:        p.Rate,
+		LyricsState: p.Song.LyricsData.LyricsState.String(),
+		Index:       index,
+		Line:        line,
+		Previous:    before,
+		Next:        after,
+	}
+}
+
+// publish sends the event to every connected stream.
+// A stream that can't keep up misses the event instead of holding up the others.
+func (o *Output) publish(name string, v any) {
+	o.subscribers.M.Lock()
+	defer o.subscribers.M.Unlock()
+
+	if len(o.subscribers.Channels) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Error("output/http", "Error marshalling the event: "+err.Error())
+		return
+	}
+
+	for ch := range o.subscribers.Channels {
+		select {
+		case ch <- event{name, data}:
+		default:
+		}
+	}
+}
+
+func (o *Output) subscribe() chan event {
+	ch := make(chan event, 16)
+
+	o.subscribers.M.Lock()
+	o.subscribers.Channels[ch] = true
+	o.subscribers.M.Unlock()
+
+	return ch
+}
+
+func (o *Output) unsubscribe(ch chan event) {
+	o.subscribers.M.Lock()
+	delete(o.subscribers.Channels, ch)
+	o.subscribers.M.Unlock()
+}
+
+func (e event) String() string {
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", e.Name, e.Data)
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+
+	httplib "net/http"
+)
+
+// handleSnapshot serves the current state as JSON
+func (o *Output) handleSnapshot(w httplib.ResponseWriter, r *httplib.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(o.snapshot()); err != nil {
+		log.Debug("output/http", "Failed to send the snapshot: "+err.Error())
+	}
+}
+
+// handleEvents streams the changes of the state as Server-Sent Events.
+// The current state is sent right away as a "line" event.
+func (o *Output) handleEvents(w httplib.ResponseWriter, r *httplib.Request) {
+	flusher, ok := w.(httplib.Flusher)
+	if !ok {
+		httplib.Error(w, "Streaming is not supported", httplib.StatusInternalServerError)
+		return
+	}
+
+	ch := o.subscribe()
+	defer o.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	data, _ := json.Marshal(o.snapshot())
+	fmt.Fprint(w, event{"line", data})
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case e := <-ch:
+			fmt.Fprint(w, e)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleTheme serves the configured theme as CSS variables
+// followed by the configured stylesheet
+func (o *Output) handleTheme(w httplib.ResponseWriter, r *httplib.Request) {
+	global.Config.M.Lock()
+	theme := o.config().Theme
+	global.Config.M.Unlock()
+
+	var b strings.Builder
+	b.WriteString(":root {\n")
+	for _, v := range [][2]string{
+		{"--font", theme.Font},
+		{"--font-size", theme.FontSize},
+		{"--color", theme.Color},
+		{"--dim-color", theme.DimColor},
+		{"--background", theme.Background},
+		{"--align", theme.Align},
+	} {
+		if v[1] != "" {
+			fmt.Fprintf(&b, "  %s: %s;\n", v[0], v[1])
+		}
+	}
+	fmt.Fprintf(&b, "  --transition: %gs;\n}\n", theme.Transition)
+
+	if theme.Stylesheet != "" {
+		stylesheet, err := os.ReadFile(os.ExpandEnv(theme.Stylesheet))
+		if err != nil {
+			log.Warn("output/http", "Failed to read the stylesheet: "+err.Error())
+		} else {
+			b.WriteString("\n")
+			b.Write(stylesheet)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+
+	httplib "net/http"
+)
+
+//go:embed web
+var files embed.FS
+
+const (
+	// readHeaderTimeout is how long a client has to send the request headers
+	readHeaderTimeout = 10 * time.Second
+	// idleTimeout is how long an idle keep-alive connection is kept open.
+	// There's no write timeout, since the event stream is written to indefinitely
+	idleTimeout = 2 * time.Minute
+)
+
+// Output is an HTTP server with a browser overlay (e.g. for OBS),
+// a snapshot of the current state and a stream of its changes.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
+
+	M       sync.Mutex
+	server  *httplib.Server
+	address string
+
+	// lyricIndex is the index of the last displayed lyric
+	lyricIndex atomic.Int64
+
+	subscribers struct {
+		M        sync.Mutex
+		Channels map[chan event]bool
+	}
+}
+
+// New creates the HTTP output with the name and starts serving on its address.
+func New(name string) (*Output, error) {
+	o := &Output{name: name}
+	o.lyricIndex.Store(-1)
+	o.subscribers.Channels = make(map[chan event]bool)
+
+	global.Config.M.Lock()
+	address := o.config().Address
+	global.Config.M.Unlock()
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if err := o.listen(address); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.HTTPOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.HTTP
+}
+
+// listen starts serving on the address.
+// Must be called while holding the output's mutex.
+func (o *Output) listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Error("output/http", "Failed to listen on "+address+": "+err.Error())
+		return err
+	}
+
+	web, _ := fs.Sub(files, "web")
+	mux := httplib.NewServeMux()
+	mux.Handle("GET /", httplib.FileServerFS(web))
+	mux.HandleFunc("GET /theme.css", o.handleTheme)
+	mux.HandleFunc("GET /snapshot", o.handleSnapshot)
+	mux.HandleFunc("GET /events", o.handleEvents)
+
+	server := &httplib.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, httplib.ErrServerClosed) {
+			log.Error("output/http", "The server has stopped: "+err.Error())
+		}
+	}()
+
+	o.server, o.address = server, address
+	log.Info("output/http", "Serving the overlay on http://"+address)
+	return nil
+}
+
+// Close stops the server and drops all the connections.
+func (o *Output) Close() {
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if o.server != nil {
+		o.server.Close()
+		o.server = nil
+	}
+}
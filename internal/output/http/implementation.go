@@ -0,0 +1,39 @@
+package http
+
+import "lrcsnc/internal/pkg/global"
+
+func (o *Output) OnConfigUpdate() {
+	global.Config.M.Lock()
+	address := o.config().Address
+	global.Config.M.Unlock()
+
+	o.M.Lock()
+	if o.server != nil && address != o.address {
+		// The old address is kept if the new one is unavailable
+		old := o.server
+		if o.listen(address) == nil {
+			old.Close()
+		}
+	}
+	o.M.Unlock()
+
+	// The overlay loads the theme again
+	o.publish("config", struct{}{})
+}
+
+func (o *Output) OnPlayerUpdate() {
+	o.publish("song", o.snapshot())
+}
+
+func (o *Output) OnOverwrite(overwrite string) {
+	o.publish("overwrite", Overwrite{overwrite})
+}
+
+func (o *Output) OnPositionUpdate() {
+	o.publish("position", o.snapshot())
+}
+
+func (o *Output) DisplayLyric(lyricIndex int) {
+	o.lyricIndex.Store(int64(lyricIndex))
+	o.publish("line", o.snapshot())
+}
@@ -0,0 +1,64 @@
+"use strict";
+
+const body = document.body;
+const previous = document.getElementById("previous");
+const current = document.getElementById("current");
+const next = document.getElementById("next");
+const overwrite = document.getElementById("overwrite");
+
+const overwriteDuration = 5000;
+let overwriteTimer;
+let shownIndex = null;
+let shownLine = null;
+
+function setLines(element, lines) {
+	element.replaceChildren(...lines.map((text) => {
+		const line = document.createElement("div");
+		line.textContent = text;
+		return line;
+	}));
+}
+
+function render(snapshot) {
+	body.className = snapshot.status.toLowerCase() + " " + snapshot.lyrics_state.toLowerCase();
+	if (snapshot.status === "Stopped") {
+		body.classList.add("stopped");
+	}
+
+	setLines(previous, snapshot.previous);
+	setLines(next, snapshot.next);
+
+	// Only a new line is animated, not a refresh of the same one
+	if (snapshot.index === shownIndex && snapshot.line === shownLine) {
+		return;
+	}
+	shownIndex = snapshot.index;
+	shownLine = snapshot.line;
+
+	current.textContent = snapshot.line;
+	const timed = snapshot.lyrics_state === "synced" || snapshot.lyrics_state === "instrumental";
+	current.classList.toggle("instrumental", timed && snapshot.line.trim() === "");
+	current.classList.add("entering");
+	// Forces the browser to apply the starting state before the transition
+	void current.offsetWidth;
+	current.classList.remove("entering");
+}
+
+function showOverwrite(text) {
+	overwrite.textContent = text;
+	overwrite.classList.add("shown");
+	clearTimeout(overwriteTimer);
+	overwriteTimer = setTimeout(() => overwrite.classList.remove("shown"), overwriteDuration);
+}
+
+function reloadTheme() {
+	const theme = document.getElementById("theme");
+	theme.href = "theme.css?" + Date.now();
+}
+
+const events = new EventSource("events");
+for (const name of ["line", "song", "position"]) {
+	events.addEventListener(name, (e) => render(JSON.parse(e.data)));
+}
+events.addEventListener("overwrite", (e) => showOverwrite(JSON.parse(e.data).text));
+events.addEventListener("config", reloadTheme);
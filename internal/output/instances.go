@@ -0,0 +1,215 @@
+package output
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"lrcsnc/internal/output/dbus"
+	"lrcsnc/internal/output/http"
+	"lrcsnc/internal/output/mpv"
+	"lrcsnc/internal/output/notify"
+	"lrcsnc/internal/output/piped"
+	"lrcsnc/internal/output/socket"
+	"lrcsnc/internal/output/tui"
+	"lrcsnc/internal/output/webhook"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/types"
+)
+
+// running are the outputs that are currently running, by their names
+var running = struct {
+	M         sync.Mutex
+	Instances map[string]Instance
+	Types     map[string]types.OutputType
+}{
+	Instances: make(map[string]Instance),
+	Types:     make(map[string]types.OutputType),
+}
+
+// closer makes an Instance out of the outputs that are run by their packages
+type closer struct {
+	Controller
+	close func()
+}
+
+func (c closer) Close() {
+	c.close()
+}
+
+// Init starts all the configured outputs.
+func Init() error {
+	global.Config.M.Lock()
+	outputs := global.Config.C.Output.Outputs()
+	global.Config.M.Unlock()
+
+	running.M.Lock()
+	defer running.M.Unlock()
+
+	for _, name := range sortedNames(outputs) {
+		if err := start(name, outputs[name].Type); err != nil {
+			log.Error("output/Init", "Failed to start the '"+name+"' output: "+err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops all the running outputs.
+func Close() {
+	running.M.Lock()
+	defer running.M.Unlock()
+
+	for _, name := range sortedNames(running.Instances) {
+		stop(name)
+	}
+}
+
+// All returns the controller that passes everything on to all the running outputs
+func All() Controller {
+	return all{}
+}
+
+type all struct{}
+
+func (all) OnConfigUpdate() {
+	started := reconcile()
+	each(func(c Controller) { c.OnConfigUpdate() })
+	// The outputs started just now have missed the player's data
+	for _, i := range started {
+		i.OnPlayerUpdate()
+	}
+}
+
+func (all) OnPlayerUpdate() {
+	each(func(c Controller) { c.OnPlayerUpdate() })
+}
+
+func (all) OnOverwrite(overwrite string) {
+	each(func(c Controller) { c.OnOverwrite(overwrite) })
+}
+
+func (all) OnPositionUpdate() {
+	each(func(c Controller) { c.OnPositionUpdate() })
+}
+
+func (all) DisplayLyric(lyricIndex int) {
+	each(func(c Controller) { c.DisplayLyric(lyricIndex) })
+}
+
+// each calls f for every running output in the order of their names.
+// The outputs are called without holding the lock,
+// so that a slow one doesn't hold up the starting and stopping of the others.
+func each(f func(c Controller)) {
+	running.M.Lock()
+	instances := make([]Instance, 0, len(running.Instances))
+	for _, name := range sortedNames(running.Instances) {
+		instances = append(instances, running.Instances[name])
+	}
+	running.M.Unlock()
+
+	for _, i := range instances {
+		f(i)
+	}
+}
+
+// reconcile starts the outputs that were added to the config, stops the removed ones
+// and restarts the ones whose type has changed. The outputs that keep their type
+// are left running and apply their new settings in their own OnConfigUpdate.
+// Returns the outputs that were started.
+func reconcile() (started []Instance) {
+	global.Config.M.Lock()
+	outputs := global.Config.C.Output.Outputs()
+	global.Config.M.Unlock()
+
+	running.M.Lock()
+	defer running.M.Unlock()
+
+	for name, t := range running.Types {
+		if o, ok := outputs[name]; !ok || o.Type != t {
+			stop(name)
+		}
+	}
+	for _, name := range sortedNames(outputs) {
+		if _, ok := running.Instances[name]; ok {
+			continue
+		}
+		if err := start(name, outputs[name].Type); err != nil {
+			log.Error("output/reconcile", "Failed to start the '"+name+"' output: "+err.Error())
+			continue
+		}
+		started = append(started, running.Instances[name])
+	}
+
+	return
+}
+
+// start starts the output with the name.
+// Must be called while holding the lock.
+func start(name string, t types.OutputType) error {
+	var i Instance
+	switch t {
+	case types.OutputPiped:
+		i = piped.New(name)
+		log.Info("output", "Piped output '"+name+"' initialized.")
+	case types.OutputDBus:
+		if err := dbus.Init(); err != nil {
+			return err
+		}
+		i = closer{dbus.Controller{}, dbus.Close}
+		log.Info("output", "D-Bus output '"+name+"' initialized.")
+	case types.OutputHTTP:
+		o, err := http.New(name)
+		if err != nil {
+			return err
+		}
+		i = o
+		log.Info("output", "HTTP output '"+name+"' initialized.")
+	case types.OutputSocket:
+		o, err := socket.New(name)
+		if err != nil {
+			return err
+		}
+		i = o
+		log.Info("output", "Socket output '"+name+"' initialized.")
+	case types.OutputNotify:
+		o, err := notify.New(name)
+		if err != nil {
+			return err
+		}
+		i = o
+		log.Info("output", "Notification output '"+name+"' initialized.")
+	case types.OutputMPV:
+		i = mpv.New(name)
+		log.Info("output", "mpv output '"+name+"' initialized.")
+	case types.OutputWebhook:
+		i = webhook.New(name)
+		log.Info("output", "Webhook output '"+name+"' initialized.")
+	case types.OutputTUI:
+		tui.Init(name)
+		i = closer{tui.Controller{}, tui.Close}
+		log.Info("output", "Terminal UI output '"+name+"' initialized.")
+	default:
+		return errs.ErrUnknownOutputType
+	}
+
+	running.Instances[name] = i
+	running.Types[name] = t
+	return nil
+}
+
+// stop stops the output with the name.
+// Must be called while holding the lock.
+func stop(name string) {
+	running.Instances[name].Close()
+	delete(running.Instances, name)
+	delete(running.Types, name)
+	log.Info("output", "Output '"+name+"' stopped.")
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	return slices.Sorted(maps.Keys(m))
+}
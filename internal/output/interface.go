@@ -1,18 +1,16 @@
 package output
 
-import (
-	"lrcsnc/internal/output/piped"
-	"lrcsnc/internal/pkg/types"
-)
-
 type Controller interface {
 	OnConfigUpdate()
 	OnPlayerUpdate()
 	OnOverwrite(overwrite string)
+	OnPositionUpdate()
 
 	DisplayLyric(lyricIndex int)
 }
 
-var Controllers = map[types.OutputType]Controller{
-	types.OutputPiped: piped.Controller{},
+// Instance is a running output
+type Instance interface {
+	Controller
+	Close()
 }
@@ -0,0 +1,101 @@
+package mpv
+
+import (
+	"strings"
+	"time"
+
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/types"
+)
+
+func (o *Output) OnConfigUpdate() {}
+
+func (o *Output) OnPlayerUpdate() {}
+
+func (o *Output) OnOverwrite(overwrite string) {
+	global.Config.M.Lock()
+	c := *o.config()
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	player := global.Player.P.Name
+	global.Player.M.Unlock()
+
+	if !strings.Contains(player, c.Player) {
+		return
+	}
+
+	duration := time.Duration(c.Duration * float64(time.Second))
+	o.M.Lock()
+	o.overwriteUntil = time.Now().Add(duration)
+	o.M.Unlock()
+	o.enqueue(osdText{c.Socket, overwrite, duration})
+
+	// The line comes back once the overwrite is gone
+	time.AfterFunc(duration, func() {
+		o.M.Lock()
+		lyricIndex := o.lineIndex
+		o.M.Unlock()
+		o.display(lyricIndex, true)
+	})
+}
+
+// OnPositionUpdate shows the line again for the time left of it,
+// since it may have changed after a seek, a pause or a change of the rate
+func (o *Output) OnPositionUpdate() {
+	o.M.Lock()
+	lyricIndex := o.lineIndex
+	o.M.Unlock()
+	o.display(lyricIndex, false)
+}
+
+func (o *Output) DisplayLyric(lyricIndex int) {
+	o.display(lyricIndex, true)
+}
+
+// display shows the line with the index until the next one starts.
+// An empty line or the time outside of the lyrics clears the OSD.
+// If it's the same line as before, it's only shown again if its end has moved noticeably.
+func (o *Output) display(lyricIndex int, changed bool) {
+	timestampOffset := offset.Get()
+
+	global.Config.M.Lock()
+	c := *o.config()
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	p := global.Player.P
+	global.Player.M.Unlock()
+
+	if !strings.Contains(p.Name, c.Player) || p.Song.LyricsData.LyricsState != types.LyricsStateSynced {
+		return
+	}
+
+	lyrics := p.Song.LyricsData.Lyrics
+	text := ""
+	if lyricIndex >= 0 && lyricIndex < len(lyrics) {
+		text = strings.TrimSpace(lyrics[lyricIndex].Text)
+	}
+	duration := LineDuration(p, lyricIndex, timestampOffset, time.Duration(c.Duration*float64(time.Second)))
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	end := time.Now().Add(duration)
+	if !changed && lyricIndex == o.lineIndex {
+		if diff := end.Sub(o.lineEnd); diff > -250*time.Millisecond && diff < 250*time.Millisecond {
+			return
+		}
+	}
+	o.lineIndex, o.lineEnd = lyricIndex, end
+	// The line is shown once the overwrite is gone
+	if time.Now().Before(o.overwriteUntil) {
+		return
+	}
+
+	if text != "" {
+		text = formatLine(c.Format, text, p.Song)
+	}
+	o.enqueue(osdText{c.Socket, text, duration})
+}
@@ -0,0 +1,139 @@
+package mpv
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+)
+
+const dialTimeout = time.Second
+
+// Output shows the current line on mpv's on-screen display through its JSON IPC
+// while mpv is the active player.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
+
+	// conn is the connection to mpv's socket. It's made when there is something to show,
+	// since mpv may be started (or restarted) long after lrcsnc
+	conn net.Conn
+
+	// queue holds the text that is yet to be shown.
+	// Only the latest one matters, so the older one is dropped if mpv is slow
+	queue chan osdText
+	done  chan bool
+
+	// The last shown line, not to show it again on every position update
+	M         sync.Mutex
+	lineIndex int
+	lineEnd   time.Time
+	// overwriteUntil is when the overwrite stops hiding the lines
+	overwriteUntil time.Time
+}
+
+// osdText is the text shown on the OSD for the duration
+type osdText struct {
+	Socket   string
+	Text     string
+	Duration time.Duration
+}
+
+// New creates the mpv output with the name.
+func New(name string) *Output {
+	o := &Output{
+		name:      name,
+		queue:     make(chan osdText, 1),
+		done:      make(chan bool),
+		lineIndex: -1,
+	}
+	go o.sender()
+
+	return o
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.MPVOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.MPV
+}
+
+// enqueue puts the text in place of the one that is not shown yet
+func (o *Output) enqueue(t osdText) {
+	for {
+		select {
+		case o.queue <- t:
+			return
+		default:
+			select {
+			case <-o.queue:
+			default:
+			}
+		}
+	}
+}
+
+// sender shows the queued texts, so that mpv doesn't hold up the lyrics
+func (o *Output) sender() {
+	for {
+		select {
+		case t := <-o.queue:
+			o.show(t)
+		case <-o.done:
+			if o.conn != nil {
+				o.conn.Close()
+			}
+			return
+		}
+	}
+}
+
+// show sends the show-text command to mpv, connecting to it first if needed
+func (o *Output) show(t osdText) {
+	command, _ := json.Marshal(map[string]any{
+		"command": []any{"show-text", t.Text, t.Duration.Milliseconds()},
+	})
+	command = append(command, '\n')
+
+	// The connection may be broken if mpv has been restarted, so one more attempt is made with a new one
+	for range 2 {
+		if o.conn == nil && !o.connect(t.Socket) {
+			return
+		}
+		o.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		if _, err := o.conn.Write(command); err == nil {
+			return
+		}
+		o.conn.Close()
+		o.conn = nil
+	}
+}
+
+// connect connects to mpv's socket
+func (o *Output) connect(socket string) bool {
+	conn, err := net.DialTimeout("unix", os.ExpandEnv(socket), dialTimeout)
+	if err != nil {
+		log.Debug("output/mpv", "Failed to connect to mpv: "+err.Error())
+		return false
+	}
+	log.Debug("output/mpv", "Connected to mpv on '"+socket+"'")
+
+	// mpv answers every command and sends its events,
+	// which are not needed, but have to be read not to clog the socket
+	go io.Copy(io.Discard, conn)
+
+	o.conn = conn
+	return true
+}
+
+// Close stops showing the lines and disconnects from mpv.
+func (o *Output) Close() {
+	close(o.done)
+}
@@ -0,0 +1,43 @@
+package mpv
+
+import (
+	"strings"
+	"time"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// formatLine fills the line and the song's data into the format
+func formatLine(format string, lyric string, song structs.Song) string {
+	if format == "" {
+		return lyric
+	}
+
+	vars := template.SongVars(song)
+	vars["lyric"] = lyric
+	return strings.TrimSpace(template.Render(format, vars, nil))
+}
+
+// LineDuration returns how long the line with the index is shown on the OSD:
+// until the next line starts while playing, or for the fallback duration
+// if it's the last line or the playback is not going on.
+// An empty line (or none at all) is not shown, so that the OSD is cleared.
+func LineDuration(p structs.Player, lyricIndex int, timestampOffset float64, fallback time.Duration) time.Duration {
+	lyrics := p.Song.LyricsData.Lyrics
+	if lyricIndex < 0 || lyricIndex >= len(lyrics) || strings.TrimSpace(lyrics[lyricIndex].Text) == "" {
+		return 0
+	}
+	if p.PlaybackStatus != mprislib.PlaybackPlaying || lyricIndex+1 >= len(lyrics) {
+		return fallback
+	}
+
+	rate := p.Rate
+	if rate <= 0 {
+		rate = 1
+	}
+	left := (lyrics[lyricIndex+1].Time + timestampOffset + p.Song.LyricsData.Offset - p.Position) / rate
+	return time.Duration(max(left, 0) * float64(time.Second))
+}
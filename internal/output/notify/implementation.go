@@ -0,0 +1,57 @@
+package notify
+
+import "lrcsnc/internal/pkg/global"
+
+func (o *Output) OnConfigUpdate() {}
+
+func (o *Output) OnPlayerUpdate() {
+	global.Config.M.Lock()
+	c := *o.config()
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+
+	n, ok := SongNotification(c, song)
+	if !ok {
+		return
+	}
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if id := song.ID(); id != o.songID {
+		o.songID = id
+		o.enqueue(n)
+	}
+}
+
+func (o *Output) OnOverwrite(overwrite string) {}
+
+func (o *Output) OnPositionUpdate() {}
+
+func (o *Output) DisplayLyric(lyricIndex int) {
+	global.Config.M.Lock()
+	c := *o.config()
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+
+	n, ok := LineNotification(c, song, lyricIndex)
+	if !ok {
+		return
+	}
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if lyricIndex == o.lineIndex && n.Body == o.lineText {
+		return
+	}
+	o.lineIndex, o.lineText = lyricIndex, n.Body
+
+	o.enqueue(n)
+}
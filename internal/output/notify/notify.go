@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"strings"
+	"sync"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+
+	dbuslib "github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsName = "org.freedesktop.Notifications"
+	notificationsPath = "/org/freedesktop/Notifications"
+	appName           = "lrcsnc"
+	// defaultIcon is used if the player doesn't give a local album art
+	defaultIcon = "audio-x-generic"
+)
+
+// markupEscaper escapes the text for the notification servers that support markup
+var markupEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Output sends a desktop notification for every lyric line
+// or for every song, replacing the previous one.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
+	conn *dbuslib.Conn
+
+	// queue holds the notification that is yet to be sent.
+	// Only the latest one matters, so the older one is dropped if the server is slow
+	queue chan Notification
+	done  chan bool
+
+	// id is the ID of the last notification, which the next one replaces.
+	// Only the sender goroutine uses it
+	id uint32
+
+	// The last shown things, not to send the same notification again
+	M         sync.Mutex
+	lineIndex int
+	lineText  string
+	songID    uint64
+}
+
+// Notification is a desktop notification with the settings it is sent with
+type Notification struct {
+	Summary string
+	Body    string
+	Icon    string
+	Config  structs.NotifyOutputConfig
+}
+
+// New connects to the session bus and creates the notification output with the name.
+func New(name string) (*Output, error) {
+	// The connection is private, the same as the D-Bus output's one
+	conn, err := dbuslib.SessionBusPrivate()
+	if err != nil {
+		log.Error("output/notify", err.Error())
+		return nil, err
+	}
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		log.Error("output/notify", err.Error())
+		return nil, err
+	}
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+		log.Error("output/notify", err.Error())
+		return nil, err
+	}
+
+	o := &Output{
+		name:      name,
+		conn:      conn,
+		queue:     make(chan Notification, 1),
+		done:      make(chan bool),
+		lineIndex: -1,
+	}
+	go o.sender()
+
+	return o, nil
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.NotifyOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.Notify
+}
+
+// enqueue puts the notification in place of the one that is not sent yet
+func (o *Output) enqueue(n Notification) {
+	for {
+		select {
+		case o.queue <- n:
+			return
+		default:
+			select {
+			case <-o.queue:
+			default:
+			}
+		}
+	}
+}
+
+// sender sends the queued notifications, so that a slow notification server
+// doesn't hold up the lyrics
+func (o *Output) sender() {
+	for {
+		select {
+		case n := <-o.queue:
+			o.send(n)
+		case <-o.done:
+			// The last notification and the connection are closed here,
+			// so that nothing is sent on them anymore
+			if o.id != 0 {
+				o.conn.Object(notificationsName, notificationsPath).Call(notificationsName+".CloseNotification", 0, o.id)
+			}
+			if err := o.conn.Close(); err != nil {
+				log.Error("output/notify", err.Error())
+			}
+			return
+		}
+	}
+}
+
+// send sends the notification, replacing the previous one
+func (o *Output) send(n Notification) {
+	hints := map[string]dbuslib.Variant{
+		"urgency": dbuslib.MakeVariant(n.Config.Urgency.Byte()),
+		// Every line is not worth keeping in the notification history
+		"transient": dbuslib.MakeVariant(n.Config.Mode != types.NotifySong),
+	}
+	icon := defaultIcon
+	if path := iconPath(n.Icon); path != "" {
+		icon = path
+		hints["image-path"] = dbuslib.MakeVariant(path)
+	}
+
+	timeout := int32(-1)
+	if n.Config.Timeout >= 0 {
+		timeout = int32(n.Config.Timeout * 1000)
+	}
+
+	var id uint32
+	err := o.conn.Object(notificationsName, notificationsPath).Call(notificationsName+".Notify", 0,
+		appName, o.id, icon, n.Summary, markupEscaper.Replace(n.Body), []string{}, hints, timeout,
+	).Store(&id)
+	if err != nil {
+		log.Error("output/notify", "Failed to send the notification: "+err.Error())
+		return
+	}
+	o.id = id
+}
+
+// Close stops sending the notifications and closes the last one along with the connection.
+func (o *Output) Close() {
+	close(o.done)
+}
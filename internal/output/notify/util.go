@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"net/url"
+	"strings"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
+	"lrcsnc/internal/pkg/types"
+)
+
+// SongNotification returns the notification about the song in the "song" mode.
+// It's sent once the lyrics are there, so false is returned while they are loading
+// as well as in the other modes.
+func SongNotification(c structs.NotifyOutputConfig, song structs.Song) (Notification, bool) {
+	if c.Mode != types.NotifySong {
+		return Notification{}, false
+	}
+	if song.LyricsData.LyricsState == types.LyricsStateLoading || song.LyricsData.LyricsState == types.LyricsStateUnknown {
+		return Notification{}, false
+	}
+
+	return Notification{
+		Summary: formatSummary(c.Summary, song),
+		Body:    strings.Join(firstLines(song.LyricsData, int(c.Lines)), "\n"),
+		Icon:    song.ArtURL,
+		Config:  c,
+	}, true
+}
+
+// LineNotification returns the notification about the line with the index
+// in the modes other than "song". Only the lines that are sung are worth a notification,
+// so false is returned for the empty ones and the time outside of the lyrics.
+func LineNotification(c structs.NotifyOutputConfig, song structs.Song, lyricIndex int) (Notification, bool) {
+	if c.Mode == types.NotifySong {
+		return Notification{}, false
+	}
+	if lyricIndex < 0 || lyricIndex >= len(song.LyricsData.Lyrics) {
+		return Notification{}, false
+	}
+	text := song.LyricsData.Lyrics[lyricIndex].Text
+	if strings.TrimSpace(text) == "" {
+		return Notification{}, false
+	}
+
+	return Notification{
+		Summary: formatSummary(c.Summary, song),
+		Body:    text,
+		Icon:    song.ArtURL,
+		Config:  c,
+	}, true
+}
+
+// formatSummary fills the song's data into the summary format
+func formatSummary(format string, song structs.Song) string {
+	return strings.TrimSpace(template.Render(format, template.SongVars(song), nil))
+}
+
+// firstLines returns up to n first non-empty lines of the lyrics
+func firstLines(lyricsData structs.LyricsData, n int) []string {
+	lines := make([]string, 0, n)
+	for _, l := range lyricsData.Lyrics {
+		if len(lines) == n {
+			break
+		}
+		if strings.TrimSpace(l.Text) != "" {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines
+}
+
+// iconPath returns the local path of the album art, or an empty string
+// if the art is remote or the URL is invalid. The notification servers
+// only take the paths and the icon names as icons
+func iconPath(artURL string) string {
+	u, err := url.Parse(artURL)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return ""
+	}
+	return u.Path
+}
@@ -1,5 +0,0 @@
-package piped
-
-var (
-	currentLyricChangedChan = make(chan int)
-)
@@ -0,0 +1,131 @@
+package piped
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/output/piped/json"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/types"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// gap is the instrumental gap that is being counted down
+type gap struct {
+	M      sync.Mutex
+	Active bool
+	// End is when the gap ends while playing...
+	End time.Time
+	// ...and Left is how much of it is left while paused
+	Left   time.Duration
+	Paused bool
+}
+
+// updateGap checks if the lyric with the index opens an instrumental gap
+// long enough for a countdown and finds out when the gap ends.
+// Returns true if the countdown has changed noticeably (e.g. after a seek).
+func (o *Output) updateGap(lyricIndex int) bool {
+	timestampOffset := offset.Get()
+	global.Config.M.Lock()
+	countdown := o.config().Instrumental.Countdown
+	introCountdown := global.Config.C.Output.Intro.Behaviour == types.IntroCountdown
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	var left time.Duration
+	var active bool
+	if lyricIndex == -1 {
+		// The intro is counted down to the first line regardless of its length
+		left, active = gapLeft(lyricIndex, timestampOffset, 0)
+		active = active && introCountdown
+	} else {
+		left, active = gapLeft(lyricIndex, timestampOffset, countdown.Threshold)
+		active = active && countdown.Enabled
+	}
+	paused := global.Player.P.PlaybackStatus != mprislib.PlaybackPlaying
+	global.Player.M.Unlock()
+
+	oldLeft, wasActive := o.gapRemaining()
+
+	o.gap.M.Lock()
+	defer o.gap.M.Unlock()
+
+	o.gap.Active, o.gap.Paused = active, paused
+	o.gap.Left, o.gap.End = left, time.Now().Add(left)
+
+	diff := oldLeft - left
+	return active != wasActive || (active && (diff > 100*time.Millisecond || diff < -100*time.Millisecond))
+}
+
+// gapLeft returns how much time is left until the line after the gap
+// if the lyric with the index is an empty line lasting at least the threshold.
+// The index -1 is the intro, which starts at the beginning of the song.
+// Does NOT lock the player mutex.
+func gapLeft(lyricIndex int, timestampOffset float64, threshold float64) (time.Duration, bool) {
+	lyricsData := global.Player.P.Song.LyricsData
+	if lyricsData.LyricsState != types.LyricsStateSynced ||
+		lyricIndex < -1 || lyricIndex >= len(lyricsData.Lyrics) ||
+		(lyricIndex >= 0 && strings.TrimSpace(lyricsData.Lyrics[lyricIndex].Text) != "") {
+		return 0, false
+	}
+
+	next := lyricIndex + 1
+	for next < len(lyricsData.Lyrics) && strings.TrimSpace(lyricsData.Lyrics[next].Text) == "" {
+		next++
+	}
+	// The gaps after the last line are not followed by anything to count down to
+	if next >= len(lyricsData.Lyrics) {
+		return 0, false
+	}
+	start := 0.0
+	if lyricIndex >= 0 {
+		start = lyricsData.Lyrics[lyricIndex].Time
+	}
+	if lyricsData.Lyrics[next].Time-start < threshold {
+		return 0, false
+	}
+
+	rate := global.Player.P.Rate
+	if rate <= 0 {
+		rate = 1
+	}
+	left := (lyricsData.Lyrics[next].Time + timestampOffset + lyricsData.Offset - global.Player.P.Position) / rate
+	return time.Duration(max(left, 0) * float64(time.Second)), true
+}
+
+// gapRemaining returns the time left until the end of the instrumental gap
+// and whether there is a gap being counted down at all
+func (o *Output) gapRemaining() (time.Duration, bool) {
+	o.gap.M.Lock()
+	defer o.gap.M.Unlock()
+
+	if !o.gap.Active {
+		return 0, false
+	}
+	if o.gap.Paused {
+		return o.gap.Left, true
+	}
+	return max(time.Until(o.gap.End), 0), true
+}
+
+// untilNextSecond returns how long it takes for the countdown to show another second
+func untilNextSecond(left time.Duration) time.Duration {
+	shown := time.Duration(math.Ceil(left.Seconds())) * time.Second
+	return left - shown + time.Second + 5*time.Millisecond
+}
+
+// currentState returns the state of the output for the JSON and the placeholders.
+// It locks the config mutex, so it must not be called while holding it.
+func (o *Output) currentState() json.State {
+	left, inGap := o.gapRemaining()
+	return json.State{
+		LyricIndex:      int(o.lastLyricIndex.Load()),
+		TimestampOffset: offset.Get(),
+		Gap:             left,
+		InGap:           inGap,
+	}
+}
@@ -0,0 +1,96 @@
+package piped
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"lrcsnc/internal/control"
+	pipedjson "lrcsnc/internal/output/piped/json"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/types"
+)
+
+var (
+	// i3barStarted is set once the header and the start of the infinite array are written to stdout.
+	// The bar reads them once per process, so the restarted outputs must not write them again
+	i3barStarted atomic.Bool
+
+	// clickOwner is the output that handles the click events read from stdin
+	clickOwner  atomic.Pointer[Output]
+	readerStart sync.Once
+)
+
+// frame wraps the blocks into the i3bar protocol's infinite array if the output uses it,
+// starting it with the header on the first write
+func (o *Output) frame(s string) string {
+	if !o.outputIsStd() {
+		return s
+	}
+
+	global.Config.M.Lock()
+	defer global.Config.M.Unlock()
+
+	c := o.config()
+	if c.JSON != types.JSONOutputI3bar {
+		return s
+	}
+	if i3barStarted.CompareAndSwap(false, true) {
+		return pipedjson.FormatI3barHeader(c) + "\n[\n" + s
+	}
+	return "," + s
+}
+
+// updateClicks makes the output handle the click events if it's set to receive them.
+// Does NOT lock the config mutex.
+func (o *Output) updateClicks() {
+	c := o.config()
+	if c.JSON == types.JSONOutputI3bar && c.JSONI3bar.ClickEvents && c.Destination == "stdout" {
+		clickOwner.Store(o)
+		readerStart.Do(func() { go readClicks() })
+	} else {
+		clickOwner.CompareAndSwap(o, nil)
+	}
+}
+
+// readClicks reads the click events from stdin for as long as it's open.
+// The bar sends them as an infinite array with one event per line
+func readClicks() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if strings.TrimLeft(strings.TrimSpace(scanner.Text()), "[,") == "" {
+			continue
+		}
+
+		e, err := pipedjson.ParseI3barClick(scanner.Text())
+		if err != nil {
+			log.Debug("output/piped", "Skipping an unreadable click event: "+err.Error())
+			continue
+		}
+		if o := clickOwner.Load(); o != nil {
+			o.handleClick(e)
+		}
+	}
+}
+
+// handleClick runs the command configured for the clicked mouse button
+func (o *Output) handleClick(e pipedjson.I3barClick) {
+	global.Config.M.Lock()
+	command, ok := pipedjson.I3barClickCommand(o.config(), e)
+	global.Config.M.Unlock()
+
+	if !ok {
+		return
+	}
+
+	t, data, err := control.Parse(strings.Fields(command))
+	if err == nil {
+		_, err = control.Send(t, data)
+	}
+	if err != nil {
+		log.Warn("output/piped", "Failed to run '"+command+"' on a click: "+err.Error())
+	}
+}
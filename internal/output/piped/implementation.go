@@ -1,27 +1,53 @@
 package piped
 
-import "lrcsnc/internal/pkg/global"
+import (
+	"time"
 
-var currentDestination string = "stdout"
+	"lrcsnc/internal/pkg/global"
+)
 
-type Controller struct{}
-
-func (Controller) OnConfigUpdate() {
+func (o *Output) OnConfigUpdate() {
 	global.Config.M.Lock()
 	defer global.Config.M.Unlock()
 
-	if global.Config.C.Output.Piped.Destination != currentDestination &&
-		changeOutput(global.Config.C.Output.Piped.Destination) == nil {
-		currentDestination = global.Config.C.Output.Piped.Destination
+	if destination := o.config().Destination; destination != o.currentDestination() {
+		o.changeOutput(destination)
 	}
+	o.updateClicks()
+}
+
+func (o *Output) OnPlayerUpdate() {}
+
+func (o *Output) OnOverwrite(overwrite string) {
+	o.Overwrite(overwrite)
+}
+
+func (o *Output) OnPositionUpdate() {
+	o.positionAt.Store(time.Now().UnixNano())
+	o.refreshLine()
 }
 
-func (Controller) OnPlayerUpdate() {}
+func (o *Output) DisplayLyric(lyricIndex int) {
+	o.positionAt.Store(time.Now().UnixNano())
+	select {
+	case o.lyricChangedChan <- lyricIndex:
+	case <-o.done:
+	}
+}
 
-func (Controller) OnOverwrite(overwrite string) {
-	Overwrite(overwrite)
+// refreshLine formats the current line again, e.g. with the new progress
+func (o *Output) refreshLine() {
+	select {
+	case o.positionUpdatedChan <- true:
+	default:
+		// An update is already pending
+	}
 }
 
-func (Controller) DisplayLyric(lyricIndex int) {
-	currentLyricChangedChan <- lyricIndex
+// currentDestination returns the destination as it's set in the config
+func (o *Output) currentDestination() string {
+	if o.outputIsStd() {
+		return "stdout"
+	}
+	return o.path
 }
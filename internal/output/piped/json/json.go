@@ -5,14 +5,80 @@ import (
 	"fmt"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
 	"lrcsnc/internal/pkg/types"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func FormatToJSON(text string) string {
+// State is what the piped output knows besides the player's data
+type State struct {
+	// LyricIndex is the index of the last reached lyric
+	LyricIndex int
+	// TimestampOffset is the effective offset without the song's own offset
+	TimestampOffset float64
+	// Gap is the time left until the end of the instrumental gap if InGap is set
+	Gap   time.Duration
+	InGap bool
+}
+
+// FormatGap formats the time left until the next line as a countdown, e.g. "0:12".
+// It's rounded up so that "0:00" is never shown before the line comes.
+func FormatGap(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// progress returns the chosen kind of progress in percents
+func progress(kind types.ProgressType, s State) float64 {
+	var p float64
+	switch kind {
+	case types.ProgressSong:
+		p = global.Player.P.Progress()
+	case types.ProgressLine:
+		lyricsData := &global.Player.P.Song.LyricsData
+		p = lyricsData.LineProgress(s.LyricIndex, global.Player.P.Position-s.TimestampOffset-lyricsData.Offset, global.Player.P.Song.Duration)
+	}
+	return math.Round(p * 100)
+}
+
+// FormatI3barHeader returns the i3bar protocol's header
+func FormatI3barHeader(c *structs.PipedOutputConfig) string {
+	header, _ := json.Marshal(I3barHeader{Version: 1, ClickEvents: c.JSONI3bar.ClickEvents})
+	return string(header)
+}
+
+// ParseI3barClick parses a line of the click events' infinite array,
+// which is an event with the array's opening bracket or a separating comma before it
+func ParseI3barClick(line string) (I3barClick, error) {
+	var e I3barClick
+	err := json.Unmarshal([]byte(strings.TrimLeft(strings.TrimSpace(line), "[,")), &e)
+	return e, err
+}
+
+// I3barClickCommand returns the command (as in `lrcsnc ctl`) configured for the click,
+// or false if the click is on another block or the button has no command
+func I3barClickCommand(c *structs.PipedOutputConfig, e I3barClick) (string, bool) {
+	if e.Name != c.JSONI3bar.Name {
+		return "", false
+	}
+	command, ok := c.JSONI3bar.Clicks[strconv.Itoa(e.Button)]
+	return command, ok
+}
+
+// FormatToJSON wraps the text into the JSON format configured in c.
+// Does NOT lock the mutexes.
+func FormatToJSON(c *structs.PipedOutputConfig, text string, s State) string {
 	var jsonOutput any
 
-	switch global.Config.C.Output.Piped.JSON {
+	context := c.Context
+	before, after := global.Player.P.Song.LyricsData.Surrounding(s.LyricIndex, int(context.Previous), int(context.Next))
+
+	switch c.JSON {
 	case types.JSONOutputGeneric:
 		jsonOutput = JSONOutput{
 			Text:     text,
@@ -22,34 +88,39 @@ func FormatToJSON(text string) string {
 			Player:   global.Player.P.Name,
 			Position: fmt.Sprintf("%02d:%02d", int(global.Player.P.Position)/60, int(global.Player.P.Position)%60),
 			Duration: fmt.Sprintf("%02d:%02d", int(global.Player.P.Song.Duration)/60, int(global.Player.P.Song.Duration)%60),
+			Previous: before,
+			Next:     after,
 		}
 	case types.JSONOutputWaybar:
-		var artist string
-		if len(global.Player.P.Song.Artists) > 0 {
-			artist = global.Player.P.Song.Artists[0]
+		// {context} has whatever is shown in place of the current line, like the instrumental notes,
+		// if it's not a lyric
+		vars := Variables(c, s)
+		vars["text"] = text
+		if lyrics := global.Player.P.Song.LyricsData.Lyrics; s.LyricIndex < 0 || s.LyricIndex >= len(lyrics) || strings.TrimSpace(lyrics[s.LyricIndex].Text) == "" {
+			vars["context"] = Context(c, s, text)
+		}
+		finish := Finish(c, false)
+
+		class := strings.Split(strings.TrimSpace(template.Render(c.JSONWaybar.Class, vars, nil)), " ")
+		if s.InGap {
+			class = append(class, "instrumental-gap")
 		}
-		altTooltipReplacer := strings.NewReplacer(
-			"{text}", text,
-			"{artist}", artist,
-			"{artists}", strings.Join(global.Player.P.Song.Artists, ", "),
-			"{title}", global.Player.P.Song.Title,
-			"{album}", global.Player.P.Song.Album,
-			"{position}", fmt.Sprintf("%02d:%02d", int(global.Player.P.Position)/60, int(global.Player.P.Position)%60),
-			"{duration}", fmt.Sprintf("%02d:%02d", int(global.Player.P.Song.Duration)/60, int(global.Player.P.Song.Duration)%60),
-		)
-
-		classReplacer := strings.NewReplacer(
-			"{playback-status}", strings.ToLower(string(global.Player.P.PlaybackStatus)),
-			"{lyrics-status}", strings.ToLower(global.Player.P.Song.LyricsData.LyricsState.String()),
-		)
 
 		jsonOutput = WaybarJSONOutput{
 			Text:       text,
-			Alt:        strings.TrimSpace(altTooltipReplacer.Replace(global.Config.C.Output.Piped.JSONWaybar.Alt)),
-			Tooltip:    strings.TrimSpace(altTooltipReplacer.Replace(global.Config.C.Output.Piped.JSONWaybar.Tooltip)),
-			Class:      strings.Split(strings.TrimSpace(classReplacer.Replace(global.Config.C.Output.Piped.JSONWaybar.Class)), " "),
-			Percentage: 0,
+			Alt:        strings.TrimSpace(template.Render(c.JSONWaybar.Alt, vars, finish)),
+			Tooltip:    strings.TrimSpace(template.Render(c.JSONWaybar.Tooltip, vars, finish)),
+			Class:      class,
+			Percentage: progress(c.JSONWaybar.Percentage, s),
 		}
+	case types.JSONOutputI3bar:
+		state := global.Player.P.Song.LyricsData.LyricsState.String()
+		jsonOutput = []I3barBlock{{
+			FullText: text,
+			Name:     c.JSONI3bar.Name,
+			Color:    c.JSONI3bar.Colors[state],
+			Urgent:   slices.Contains(c.JSONI3bar.Urgent, state),
+		}}
 	}
 
 	jsonData, err := json.Marshal(jsonOutput)
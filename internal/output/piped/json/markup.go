@@ -0,0 +1,39 @@
+package json
+
+import (
+	"strings"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// pangoEscaper escapes the text for Pango, which Waybar renders the text and the tooltip with
+var pangoEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&#39;", `"`, "&quot;")
+
+// Escape escapes the text for Pango if the output is Waybar's.
+// The formats and the markup templates are markup themselves,
+// so only the values put into them (lyrics, song data, icons and messages) are escaped.
+func Escape(c *structs.PipedOutputConfig, s string) string {
+	if c.JSON != types.JSONOutputWaybar {
+		return s
+	}
+	return pangoEscaper.Replace(s)
+}
+
+// Style wraps the part into its markup template if the output is Waybar's and the template is set.
+// The part is expected to be escaped already.
+func Style(c *structs.PipedOutputConfig, template, part string) string {
+	if c.JSON != types.JSONOutputWaybar || template == "" || part == "" {
+		return part
+	}
+	return strings.ReplaceAll(template, "{value}", part)
+}
+
+// escapeAll escapes every string in the slice
+func escapeAll(c *structs.PipedOutputConfig, s []string) []string {
+	escaped := make([]string, len(s))
+	for i := range s {
+		escaped[i] = Escape(c, s[i])
+	}
+	return escaped
+}
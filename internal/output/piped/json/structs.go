@@ -1,13 +1,35 @@
 package json
 
 type JSONOutput struct {
-	Text     string `json:"text"`
-	Title    string `json:"song"`
-	Artist   string `json:"artist"`
-	Album    string `json:"album"`
-	Player   string `json:"player"`
-	Position string `json:"position"`
-	Duration string `json:"duration"`
+	Text     string   `json:"text"`
+	Title    string   `json:"song"`
+	Artist   string   `json:"artist"`
+	Album    string   `json:"album"`
+	Player   string   `json:"player"`
+	Position string   `json:"position"`
+	Duration string   `json:"duration"`
+	Previous []string `json:"previous"`
+	Next     []string `json:"next"`
+}
+
+// I3barBlock is a block of the i3bar protocol's status line
+type I3barBlock struct {
+	FullText string `json:"full_text"`
+	Name     string `json:"name"`
+	Color    string `json:"color,omitempty"`
+	Urgent   bool   `json:"urgent,omitempty"`
+}
+
+// I3barClick is a click on a block sent by the bar
+type I3barClick struct {
+	Name   string `json:"name"`
+	Button int    `json:"button"`
+}
+
+// I3barHeader is the first thing sent to the bar in the i3bar protocol
+type I3barHeader struct {
+	Version     int  `json:"version"`
+	ClickEvents bool `json:"click_events"`
 }
 
 type WaybarJSONOutput struct {
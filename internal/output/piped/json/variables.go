@@ -0,0 +1,114 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
+	"lrcsnc/internal/pkg/types"
+	"lrcsnc/internal/practice"
+)
+
+// Variables returns the variables for the state, which are the same in every format
+// (their names are template.PipedNames).
+// {lyric} and {multiplier} are of the lyric at the state's index, and {text} is empty,
+// as it's only known once the text format is rendered.
+// Does NOT lock the mutexes.
+func Variables(c *structs.PipedOutputConfig, s State) template.Vars {
+	p := &global.Player.P
+	lyricsData := &p.Song.LyricsData
+
+	vars := template.SongVars(p.Song)
+	vars["icon"] = c.Lyric.Icon
+	vars["symbol"] = c.Instrumental.Symbol
+	vars["player"] = p.Name
+	vars["position"] = fmt.Sprintf("%02d:%02d", int(p.Position)/60, int(p.Position)%60)
+	vars["duration"] = fmt.Sprintf("%02d:%02d", int(p.Song.Duration)/60, int(p.Song.Duration)%60)
+	vars["playback-status"] = strings.ToLower(string(p.PlaybackStatus))
+	vars["lyrics-status"] = strings.ToLower(lyricsData.LyricsState.String())
+	vars["song-progress"] = strconv.Itoa(int(progress(types.ProgressSong, s)))
+	vars["line-progress"] = strconv.Itoa(int(progress(types.ProgressLine, s)))
+	vars["text"] = ""
+
+	if s.InGap {
+		vars["gap-remaining"] = FormatGap(s.Gap)
+	}
+
+	before, after := lyricsData.Surrounding(s.LyricIndex, 1, 2)
+	if len(before) > 0 {
+		vars["prev"] = before[0]
+	}
+	if len(after) > 0 {
+		vars["next"] = after[0]
+	}
+	if len(after) > 1 {
+		vars["next2"] = after[1]
+	}
+
+	var lyric string
+	if s.LyricIndex >= 0 && s.LyricIndex < len(lyricsData.Lyrics) {
+		lyric = lyricsData.Lyrics[s.LyricIndex].Text
+	}
+	vars["lyric"] = lyric
+	vars["context"] = Context(c, s, Escape(c, lyric))
+
+	// The multiplier shows how many times the line is repeated,
+	// or how many times the lines were looped while practicing
+	value := 0
+	if l, ok := practice.Get(); ok {
+		value = l.Count
+	} else if strings.TrimSpace(lyric) != "" {
+		for i := s.LyricIndex; i >= 0 && lyricsData.Lyrics[i].Text == lyric; i-- {
+			value++
+		}
+		if value < 2 {
+			value = 0
+		}
+	}
+	if value > 0 {
+		vars["value"] = strconv.Itoa(value)
+		vars["multiplier"] = template.Render(c.Multiplier.Format, vars, Finish(c, false))
+	}
+
+	return vars
+}
+
+// Context returns a small scroll of the lyrics with the current line in the middle.
+// The lines are escaped for Waybar, while the current one is expected to be escaped already.
+// Does NOT lock the mutexes.
+func Context(c *structs.PipedOutputConfig, s State, current string) string {
+	before, after := global.Player.P.Song.LyricsData.Surrounding(s.LyricIndex, int(c.Context.Previous), int(c.Context.Next))
+	scroll := append(append(escapeAll(c, before), current), escapeAll(c, after)...)
+	return strings.Join(scroll, "\n")
+}
+
+// Finish escapes the variables for Waybar and wraps the parts into their markup templates.
+// The lyric is styled as a state message if message is set
+func Finish(c *structs.PipedOutputConfig, message bool) template.Finish {
+	markup := c.JSONWaybar.Markup
+	return func(name, value string) string {
+		switch name {
+		case "text", "context":
+			// These are made of the escaped parts already
+			return value
+		case "multiplier":
+			// The multiplier is rendered from its own format
+			return Style(c, markup.Multiplier, value)
+		}
+
+		value = Escape(c, value)
+		switch name {
+		case "icon":
+			return Style(c, markup.Icon, value)
+		case "lyric":
+			if message {
+				return Style(c, markup.Message, value)
+			}
+			return Style(c, markup.Lyric, value)
+		}
+		return value
+	}
+}
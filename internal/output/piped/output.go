@@ -5,212 +5,411 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"lrcsnc/internal/output/piped/json"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
 	"lrcsnc/internal/pkg/types"
 
 	mprislib "github.com/Endg4meZer0/go-mpris"
 )
 
-var outputPath = "/dev/stdout"
-var outputDestination = os.Stdout
+// Output is a piped output writing to its own destination.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
 
-// tempFile just adds .{pid}.tmp to the outputPath
-var tempFile string
+	path        string
+	destination *os.File
+	// tempFile just adds .{pid}.tmp to the path
+	tempFile string
 
-func outputIsStd() bool {
-	return outputDestination == os.Stdout ||
-		outputDestination == os.Stderr ||
-		outputDestination == os.Stdin
+	writeChan           chan string
+	lyricChangedChan    chan int
+	positionUpdatedChan chan bool
+	// refreshChan makes the writer write the string again (e.g. with the new progress),
+	// but only if the output changes. An empty string refreshes the last one
+	refreshChan chan string
+	// done is closed when the output is closed
+	done chan bool
+
+	overwrite         string
+	pendingLyricIndex int
+
+	// lastLyricIndex is the index of the last reached lyric,
+	// which the context lines are taken around
+	lastLyricIndex    atomic.Int64
+	instrumentalTimer *time.Timer
+
+	// positionAt is when the player's position was last updated (in Unix nanoseconds),
+	// which the position is extrapolated from to scroll the long lines
+	positionAt atomic.Int64
+	// scrollTimer refreshes the line when the shown part of a long one changes
+	scrollTimer *time.Timer
+
+	gap gap
 }
 
-var writeChan = make(chan string, 1)
-var overwrite = ""
-var pendingLyricIndex = -1
-var instrumentalTimer *time.Timer = time.NewTimer(5 * time.Minute)
+// New creates the piped output with the name and starts it.
+func New(name string) *Output {
+	o := &Output{
+		name:                name,
+		path:                "/dev/stdout",
+		destination:         os.Stdout,
+		writeChan:           make(chan string, 1),
+		lyricChangedChan:    make(chan int),
+		positionUpdatedChan: make(chan bool, 1),
+		refreshChan:         make(chan string),
+		done:                make(chan bool),
+		pendingLyricIndex:   -1,
+		instrumentalTimer:   time.NewTimer(5 * time.Minute),
+		scrollTimer:         time.NewTimer(time.Hour),
+	}
+	o.scrollTimer.Stop()
 
-// Init initializes... basically everything.
-func Init() {
 	// Check for config's output destination
 	global.Config.M.Lock()
-	if global.Config.C.Output.Piped.Destination != "stdout" {
-		outputPath = global.Config.C.Output.Piped.Destination
-		changeOutput(outputPath)
+	if c := o.config(); c.Destination != "stdout" {
+		o.changeOutput(c.Destination)
 	}
+	o.updateClicks()
 	global.Config.M.Unlock()
 
-	// Initialize the writer chain
-	go func() {
-		for {
-			s := <-writeChan
-			Write(s)
+	go o.writer()
+	go o.lyricListener()
+	go o.instrumentalListener()
+	go o.scrollListener()
+
+	return o
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.PipedOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.Piped
+}
+
+// writer is the writer chain
+func (o *Output) writer() {
+	last, lastOutput := "", ""
+	for {
+		refresh := false
+		select {
+		case last = <-o.writeChan:
+		case s := <-o.refreshChan:
+			if s != "" {
+				last = s
+			}
+			refresh = true
+		case <-o.done:
+			return
 		}
-	}()
 
-	// Initialize lyric change listener
-	go func() {
-		for {
-			lyricIndex := <-currentLyricChangedChan
-			if overwrite != "" {
-				pendingLyricIndex = lyricIndex
+		output := o.render(last)
+		if refresh && output == lastOutput {
+			continue
+		}
+		lastOutput = output
+		o.write(o.frame(output))
+	}
+}
+
+// lyricListener listens for the lyric and position changes
+func (o *Output) lyricListener() {
+	for {
+		var lyricIndex int
+		select {
+		case lyricIndex = <-o.lyricChangedChan:
+		case <-o.positionUpdatedChan:
+			// The line is the same, but the position is not.
+			// If the countdown of the instrumental gap is off (e.g. after a seek), it's shown again right away
+			if o.updateGap(int(o.lastLyricIndex.Load())) && o.overwrite == "" {
+				o.instrumentalTimer.Reset(1)
 				continue
 			}
-			lyric := FormatLyric(lyricIndex)
-			if lyric == "" {
-				instrumentalTimer.Reset(1)
-			} else {
-				instrumentalTimer.Stop()
-				writeChan <- lyric
+			// Otherwise overwrites and instrumental gaps keep their own pace, so only the progress is refreshed there
+			lyric, instrumental := o.formatLine(int(o.lastLyricIndex.Load()))
+			if o.overwrite != "" || instrumental {
+				lyric = ""
 			}
+			o.send(o.refreshChan, lyric)
+			continue
+		case <-o.done:
+			return
 		}
-	}()
+		o.lastLyricIndex.Store(int64(lyricIndex))
+		o.updateGap(lyricIndex)
+		if o.overwrite != "" {
+			o.pendingLyricIndex = lyricIndex
+			continue
+		}
+		lyric, instrumental := o.formatLine(lyricIndex)
+		if instrumental {
+			o.instrumentalTimer.Reset(1)
+		} else {
+			o.instrumentalTimer.Stop()
+			o.send(o.writeChan, lyric)
+		}
+	}
+}
 
-	// Initialize instrumental timer listener
-	go func() {
-		i := 1
-		for {
-			<-instrumentalTimer.C
-			global.Config.M.Lock()
-			global.Player.M.Lock()
-
-			note := global.Config.C.Output.Piped.Instrumental.Symbol
-			j := int(global.Config.C.Output.Piped.Instrumental.MaxSymbols + 1)
-
-			// Only update instrumental stuff if there is an active song
-			if global.Player.P.PlaybackStatus != mprislib.PlaybackStopped {
-				var stringToPrint string
-
-				switch global.Player.P.Song.LyricsData.LyricsState {
-				case types.LyricsStateSynced, types.LyricsStateInstrumental:
-					stringToPrint = getInstrumentalString(global.Config.C.Output.Piped.Lyric, global.Config.C.Output.Piped.Text.Format)
-				case types.LyricsStatePlain:
-					stringToPrint = getInstrumentalMessage(global.Config.C.Output.Piped.NoSyncedLyrics, global.Config.C.Output.Piped.Text.Format)
-				case types.LyricsStateNotFound:
-					stringToPrint = getInstrumentalMessage(global.Config.C.Output.Piped.SongNotFound, global.Config.C.Output.Piped.Text.Format)
-				case types.LyricsStateLoading:
-					stringToPrint = getInstrumentalMessage(global.Config.C.Output.Piped.LoadingLyrics, global.Config.C.Output.Piped.Text.Format)
-				default:
-					stringToPrint = getInstrumentalMessage(global.Config.C.Output.Piped.ErrorMessage, global.Config.C.Output.Piped.Text.Format)
-				}
+// instrumentalListener animates the instrumental parts and shows the messages
+func (o *Output) instrumentalListener() {
+	i := 1
+	for {
+		select {
+		case <-o.instrumentalTimer.C:
+		case <-o.done:
+			return
+		}
+		state := o.currentState()
+		global.Config.M.Lock()
+		global.Player.M.Lock()
 
-				if len(stringToPrint) != 0 {
-					stringToPrint += " "
-				}
-				stringToPrint += strings.Repeat(note, i%j)
+		c := o.config()
+		note := json.Escape(c, c.Instrumental.Symbol)
+		j := int(c.Instrumental.MaxSymbols + 1)
 
-				writeChan <- stringToPrint
+		// Only update instrumental stuff if there is an active song
+		if global.Player.P.PlaybackStatus != mprislib.PlaybackStopped {
+			var stringToPrint string
+
+			switch global.Player.P.Song.LyricsData.LyricsState {
+			case types.LyricsStateSynced, types.LyricsStateInstrumental:
+				stringToPrint = getInstrumentalString(c, state)
+			case types.LyricsStatePlain:
+				stringToPrint = getInstrumentalMessage(c, c.NoSyncedLyrics, state)
+			case types.LyricsStateNotFound:
+				stringToPrint = getInstrumentalMessage(c, c.SongNotFound, state)
+			case types.LyricsStateLoading:
+				stringToPrint = getInstrumentalMessage(c, c.LoadingLyrics, state)
+			default:
+				stringToPrint = getInstrumentalMessage(c, c.ErrorMessage, state)
+			}
+
+			if len(stringToPrint) != 0 {
+				stringToPrint += " "
+			}
+			// Long gaps between lines are counted down instead
+			if state.InGap {
+				stringToPrint += getCountdownString(c, state, state.Gap)
+			} else {
+				stringToPrint += strings.Repeat(note, i%j)
 
 				i++
 				if i >= j {
 					i = 1
 				}
+			}
+
+			o.send(o.writeChan, stringToPrint)
 
-				if global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying {
-					instrumentalTimer.Reset(time.Duration(global.Config.C.Output.Piped.Instrumental.Interval*1000) * time.Millisecond)
+			if global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying {
+				if state.InGap {
+					o.instrumentalTimer.Reset(untilNextSecond(state.Gap))
 				} else {
-					instrumentalTimer.Stop()
+					o.instrumentalTimer.Reset(time.Duration(c.Instrumental.Interval*1000) * time.Millisecond)
 				}
 			} else {
-				writeChan <- strings.TrimSpace(global.Config.C.Output.Piped.NotPlaying.Text)
-				instrumentalTimer.Stop()
+				o.instrumentalTimer.Stop()
 			}
-			global.Player.M.Unlock()
-			global.Config.M.Unlock()
+		} else {
+			o.send(o.writeChan, json.Style(c, c.JSONWaybar.Markup.Message, json.Escape(c, strings.TrimSpace(c.NotPlaying.Text))))
+			o.instrumentalTimer.Stop()
 		}
-	}()
+		global.Player.M.Unlock()
+		global.Config.M.Unlock()
+	}
+}
+
+// scrollListener refreshes the long lines when their shown part changes
+func (o *Output) scrollListener() {
+	for {
+		select {
+		case <-o.scrollTimer.C:
+			o.refreshLine()
+		case <-o.done:
+			return
+		}
+	}
 }
 
-// Write writes the string s to outputDestination.
-// If the outputDestination is not related to std,
+// send sends the string to the channel unless the output is closed
+func (o *Output) send(ch chan string, s string) {
+	select {
+	case ch <- s:
+	case <-o.done:
+	}
+}
+
+// Write writes the string s to the output's destination.
+// If the destination is not related to std,
 // then does its best to ensure the write is an atomic operation by using temp files.
 // If JSON output is used, it will be formatted as JSON with full data.
-func Write(s string) {
+func (o *Output) Write(s string) {
+	o.write(o.frame(o.render(s)))
+}
+
+// render turns the string s into what is written to the output destination
+func (o *Output) render(s string) string {
+	state := o.currentState()
 	global.Config.M.Lock()
 	global.Player.M.Lock()
-	if global.Config.C.Output.Piped.JSON != types.JSONOutputNone {
-		s = json.FormatToJSON(s)
+	c := o.config()
+	if c.JSON != types.JSONOutputNone {
+		s = json.FormatToJSON(c, s, state)
 	}
-	if global.Config.C.Output.Piped.InsertNewline {
+	// The i3bar protocol needs every status line to end with a newline
+	if c.InsertNewline || c.JSON == types.JSONOutputI3bar {
 		s = s + "\n"
 	}
 	global.Config.M.Unlock()
 	global.Player.M.Unlock()
 
-	if !outputIsStd() {
-		if tempDestination, err := os.OpenFile(tempFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644); err == nil {
+	return s
+}
+
+func (o *Output) write(s string) {
+	if !o.outputIsStd() {
+		if tempDestination, err := os.OpenFile(o.tempFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644); err == nil {
 			// Atomic copy (for better support of something like obs-text-pthread)
 			tempDestination.Truncate(0)
 			tempDestination.Seek(0, 0)
 			tempDestination.WriteString(s)
-			err = os.Rename(tempFile, outputPath)
+			tempDestination.Close()
+			err = os.Rename(o.tempFile, o.path)
 			if err != nil {
 				log.Error("output/piped", "Failed to move the temp file onto output destination: "+err.Error())
 				return
 			}
 		} else {
 			// If temp destination is unavailable, revert to basic handling (not atomic)
-			outputDestination.Truncate(0)
-			outputDestination.Seek(0, 0)
-			outputDestination.WriteString(s)
+			o.destination.Truncate(0)
+			o.destination.Seek(0, 0)
+			o.destination.WriteString(s)
 		}
 	} else {
-		outputDestination.WriteString(s)
+		o.destination.WriteString(s)
+	}
+}
+
+func (o *Output) outputIsStd() bool {
+	return o.destination == os.Stdout ||
+		o.destination == os.Stderr ||
+		o.destination == os.Stdin
+}
+
+// formatLine formats what is shown for the lyric with the index.
+// If it's an instrumental part, it returns true instead, as those are animated separately.
+func (o *Output) formatLine(lyricIndex int) (string, bool) {
+	if s, ok := o.formatIntroOutro(lyricIndex); ok {
+		return s, false
+	}
+	lyric := o.FormatLyric(lyricIndex)
+	return lyric, lyric == ""
+}
+
+// formatIntroOutro formats the intro (the lyric index -1) and the outro
+// (the index past the last line) of synced lyrics if their behaviours
+// show something other than the instrumental or the last line.
+func (o *Output) formatIntroOutro(lyricIndex int) (string, bool) {
+	state := o.currentState()
+	state.LyricIndex = lyricIndex
+	global.Config.M.Lock()
+	defer global.Config.M.Unlock()
+	global.Player.M.Lock()
+	defer global.Player.M.Unlock()
+
+	c := o.config()
+	song := global.Player.P.Song
+	if song.LyricsData.LyricsState != types.LyricsStateSynced {
+		return "", false
 	}
+
+	switch {
+	case lyricIndex == -1:
+		switch global.Config.C.Output.Intro.Behaviour {
+		case types.IntroTitle:
+			vars := json.Variables(c, state)
+			return strings.TrimSpace(template.Render(global.Config.C.Output.Intro.Format, vars, json.Finish(c, false))), true
+		case types.IntroEmpty:
+			return "", true
+		}
+	case lyricIndex >= len(song.LyricsData.Lyrics):
+		switch global.Config.C.Output.Outro.Behaviour {
+		case types.OutroClear:
+			return "", true
+		case types.OutroEnd:
+			return json.Style(c, c.JSONWaybar.Markup.Message, json.Escape(c, strings.TrimSpace(global.Config.C.Output.Outro.Text))), true
+		}
+	}
+
+	return "", false
 }
 
 // FormatLyric formats the lyric string (that is found by lyricIndex) to be displayed
 // in accordance with the text format configuration.
-func FormatLyric(lyricIndex int) string {
+func (o *Output) FormatLyric(lyricIndex int) string {
+	state := o.currentState()
+	state.LyricIndex = lyricIndex
 	global.Config.M.Lock()
 	defer global.Config.M.Unlock()
 	global.Player.M.Lock()
 	defer global.Player.M.Unlock()
 
+	c := o.config()
+	o.scrollTimer.Stop()
 	lyric := lyricIndexToString(lyricIndex, global.Player.P.Song.LyricsData.Lyrics)
 	if strings.TrimSpace(lyric) == "" {
 		return ""
 	}
 
-	multiplierValue := 0
-	for i := lyricIndex; i >= 0 && global.Player.P.Song.LyricsData.Lyrics[i].Text == lyric; i-- {
-		multiplierValue++
+	vars := json.Variables(c, state)
+	if c.MaxWidth > 0 {
+		vars["lyric"] = o.fitLyric(c, vars, state)
 	}
-	multiplier := ""
-	if multiplierValue > 1 {
-		multiplier = strings.ReplaceAll(global.Config.C.Output.Piped.Multiplier.Format, "{value}", strconv.Itoa(multiplierValue))
-	}
-	replacer := strings.NewReplacer(
-		"{icon}", global.Config.C.Output.Piped.Lyric.Icon,
-		"{lyric}", lyric,
-		"{multiplier}", multiplier,
-	)
-	return strings.TrimSpace(replacer.Replace(global.Config.C.Output.Piped.Text.Format))
+	return strings.TrimSpace(template.Render(c.Text.Format, vars, json.Finish(c, false)))
 }
 
 // Overwrite sets the overwrite string to be displayed.
 // Clears itself in 5 seconds.
-func Overwrite(s string) {
-	overwrite = s
-	writeChan <- overwrite
+func (o *Output) Overwrite(s string) {
+	global.Config.M.Lock()
+	s = json.Escape(o.config(), s)
+	global.Config.M.Unlock()
+
+	o.overwrite = s
+	o.send(o.writeChan, o.overwrite)
 	go func() {
 		<-time.NewTimer(5 * time.Second).C
-		overwriteEnds()
+		o.overwriteEnds()
 	}()
 }
 
-func overwriteEnds() {
-	overwrite = ""
-	currentLyricChangedChan <- pendingLyricIndex
-	pendingLyricIndex = -1
+func (o *Output) overwriteEnds() {
+	o.overwrite = ""
+	select {
+	case o.lyricChangedChan <- o.pendingLyricIndex:
+	case <-o.done:
+	}
+	o.pendingLyricIndex = -1
 }
 
 // changeOutput changes the output destination to the specified path.
 // The write check is usually performed at config validation step,
 // but it's good to have it here too.
-func changeOutput(p string) error {
+func (o *Output) changeOutput(p string) error {
+	if p == "stdout" {
+		o.closeDestination()
+		o.path, o.destination = "/dev/stdout", os.Stdout
+		return nil
+	}
+
 	newDest, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 	if err != nil {
 		log.Error("output/piped", "Error opening new output destination file, ignoring. More: "+err.Error())
@@ -219,22 +418,32 @@ func changeOutput(p string) error {
 		r := strings.NewReplacer(
 			"{pid}", strconv.Itoa(os.Getpid()),
 		)
-		tempFile = r.Replace(outputPath + ".{pid}.tmp")
+		o.tempFile = r.Replace(p + ".{pid}.tmp")
 		// We'll try to open temp file here to see if it even works
-		_, err := os.OpenFile(tempFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
-		if err != nil {
-			log.Error("output/piped", fmt.Sprintf("Failed to open a temp write file (%s). The writes will not be atomic.", tempFile))
+		if f, err := os.OpenFile(o.tempFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+			log.Error("output/piped", fmt.Sprintf("Failed to open a temp write file (%s). The writes will not be atomic.", o.tempFile))
+		} else {
+			f.Close()
 		}
 
-		outputDestination = newDest
+		o.closeDestination()
+		o.path, o.destination = p, newDest
 	}
 
 	return nil
 }
 
-// Close closes the output if it is not related to std.
-func Close() {
-	if outputDestination != nil && !outputIsStd() {
-		outputDestination.Close()
+// Close stops the output and closes its destination if it is not related to std.
+func (o *Output) Close() {
+	close(o.done)
+	clickOwner.CompareAndSwap(o, nil)
+	o.instrumentalTimer.Stop()
+	o.scrollTimer.Stop()
+	o.closeDestination()
+}
+
+func (o *Output) closeDestination() {
+	if o.destination != nil && !o.outputIsStd() {
+		o.destination.Close()
 	}
 }
@@ -0,0 +1,175 @@
+package overflow
+
+import (
+	"html"
+	"strings"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Line is the timing of a lyric line in seconds of playback
+type Line struct {
+	// Elapsed is how long ago the line started
+	Elapsed float64
+	// Length is how long the line lasts until the next one
+	Length float64
+}
+
+// Width returns how many display cells the text takes.
+// If the text is Pango markup, the tags are not counted and the entities count as the characters they are.
+func Width(s string, markup bool) int {
+	if markup {
+		s = html.UnescapeString(stripTags(s))
+	}
+	return runewidth.StringWidth(s)
+}
+
+// Fit fits the lyric into the width in display cells as the mode says.
+// It returns the part of the lyric that is shown and how many seconds of playback are left
+// until the part changes (0 if it doesn't). Without the line's timing the lyric is always truncated.
+func Fit(c structs.OverflowConfig, lyric string, width int, line *Line) (string, float64) {
+	width = max(width, 1)
+	if runewidth.StringWidth(lyric) <= width {
+		return lyric, 0
+	}
+
+	if line != nil && line.Length > 0 {
+		switch c.Mode {
+		case types.OverflowWrap:
+			return wrapped(lyric, width, *line)
+		case types.OverflowMarquee:
+			if s, left, ok := marquee(c, lyric, width, *line); ok {
+				return s, left
+			}
+		}
+	}
+
+	return runewidth.Truncate(lyric, width, c.Ellipsis), 0
+}
+
+// Wrap splits the text into parts of at most the width in display cells,
+// breaking the lines at the spaces where possible
+func Wrap(s string, width int) (parts []string) {
+	width = max(width, 1)
+
+	line := ""
+	for _, word := range strings.Fields(s) {
+		// The words that are too long by themselves (or the text without spaces, like in CJK) are broken anywhere
+		for runewidth.StringWidth(word) > width {
+			if line != "" {
+				parts = append(parts, line)
+				line = ""
+			}
+			head := runewidth.Truncate(word, width, "")
+			if head == "" {
+				// The first character alone is wider than the width
+				head = string([]rune(word)[:1])
+			}
+			parts = append(parts, head)
+			word = word[len(head):]
+		}
+
+		switch {
+		case word == "":
+		case line == "":
+			line = word
+		case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			parts = append(parts, line)
+			line = word
+		}
+	}
+	if line != "" {
+		parts = append(parts, line)
+	}
+
+	return
+}
+
+// wrapped shows the part of the wrapped lyric for the time of the line.
+// Every part is shown for the time proportional to its width
+func wrapped(lyric string, width int, line Line) (string, float64) {
+	parts := Wrap(lyric, width)
+
+	total := 0
+	for _, part := range parts {
+		total += runewidth.StringWidth(part)
+	}
+
+	cells := 0
+	for i, part := range parts {
+		cells += runewidth.StringWidth(part)
+		end := line.Length * float64(cells) / float64(total)
+		if line.Elapsed < end && i < len(parts)-1 {
+			return part, end - max(line.Elapsed, 0)
+		}
+	}
+
+	return parts[len(parts)-1], 0
+}
+
+// marquee shows the window of the lyric that is scrolled to at the time of the line.
+// It stays still for the delay at the start and at the end, scrolling fast enough
+// for the end to be seen before the next line starts
+func marquee(c structs.OverflowConfig, lyric string, width int, line Line) (string, float64, bool) {
+	over := runewidth.StringWidth(lyric) - width
+	delay := min(max(c.Delay, 0), line.Length/4)
+	speed := c.Speed
+	if scrolling := line.Length - 2*delay; scrolling > 0 {
+		speed = max(speed, float64(over)/scrolling)
+	}
+	if speed <= 0 {
+		return "", 0, false
+	}
+
+	shift := min(max(int((line.Elapsed-delay)*speed), 0), over)
+	s := window(lyric, shift, width)
+	if shift == over {
+		return s, 0, true
+	}
+
+	next := delay + float64(shift+1)/speed
+	return s, max(next-line.Elapsed, 0.01), true
+}
+
+// window returns the part of the text that starts after the skipped display cells and takes the width.
+// It's padded with spaces to the width when the wide characters don't fit exactly
+func window(s string, skip int, width int) string {
+	var b strings.Builder
+	skipped, taken := 0, 0
+	for _, r := range s {
+		w := runewidth.RuneWidth(r)
+		if skipped < skip {
+			skipped += w
+			continue
+		}
+		if taken+w > width {
+			break
+		}
+		b.WriteRune(r)
+		taken += w
+	}
+
+	return b.String() + strings.Repeat(" ", width-taken)
+}
+
+// stripTags removes the markup tags. The text itself is escaped, so every '<' starts a tag
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
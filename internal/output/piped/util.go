@@ -1,8 +1,11 @@
 package piped
 
 import (
+	"lrcsnc/internal/output/piped/json"
 	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
 	"strings"
+	"time"
 )
 
 func lyricIndexToString(l int, lyricsData []structs.Lyric) string {
@@ -13,24 +16,34 @@ func lyricIndexToString(l int, lyricsData []structs.Lyric) string {
 	}
 }
 
-func getInstrumentalMessage(c structs.MessageOutputConfig, outputFormat string) string {
+func getInstrumentalMessage(p *structs.PipedOutputConfig, c structs.MessageOutputConfig, state json.State) string {
 	if !c.Enabled {
 		return ""
 	}
 
-	replacer := strings.NewReplacer(
-		"{icon}", c.Icon,
-		"{lyric}", c.Text,
-		"{multiplier}", "",
-	)
-	return strings.TrimSpace(replacer.Replace(outputFormat))
+	vars := json.Variables(p, state)
+	vars["icon"], vars["lyric"] = c.Icon, c.Text
+	vars["multiplier"], vars["value"] = "", ""
+	return strings.TrimSpace(template.Render(p.Text.Format, vars, json.Finish(p, true)))
 }
 
-func getInstrumentalString(c structs.LyricOutputConfig, outputFormat string) string {
-	replacer := strings.NewReplacer(
-		"{icon}", c.Icon,
-		"{lyric}", "",
-		"{multiplier}", "",
-	)
-	return strings.TrimSpace(replacer.Replace(outputFormat))
+// getInstrumentalString formats the instrumental gap,
+// so that the state's variables can show where the gap is
+func getInstrumentalString(p *structs.PipedOutputConfig, state json.State) string {
+	vars := json.Variables(p, state)
+	vars["lyric"], vars["multiplier"], vars["value"] = "", "", ""
+	return strings.TrimSpace(template.Render(p.Text.Format, vars, json.Finish(p, false)))
+}
+
+// getCountdownString formats the countdown to the end of the instrumental gap
+func getCountdownString(p *structs.PipedOutputConfig, state json.State, left time.Duration) string {
+	format := p.Instrumental.Countdown.Format
+	if format == "" {
+		// The intro countdown may be used without configuring the gaps' one
+		format = "{symbol} {gap-remaining}"
+	}
+
+	vars := json.Variables(p, state)
+	vars["gap-remaining"] = json.FormatGap(left)
+	return strings.TrimSpace(template.Render(format, vars, json.Finish(p, false)))
 }
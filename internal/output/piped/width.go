@@ -0,0 +1,75 @@
+package piped
+
+import (
+	"strings"
+	"time"
+
+	"lrcsnc/internal/output/piped/json"
+	"lrcsnc/internal/output/piped/overflow"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/template"
+	"lrcsnc/internal/pkg/types"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+	"github.com/mattn/go-runewidth"
+)
+
+// fitLyric limits the lyric so that the whole text fits into the max width,
+// scheduling a refresh for when the shown part of the lyric changes.
+// Does NOT lock the mutexes.
+func (o *Output) fitLyric(c *structs.PipedOutputConfig, vars template.Vars, state json.State) string {
+	lyric := vars["lyric"]
+	text := strings.TrimSpace(template.Render(c.Text.Format, vars, json.Finish(c, false)))
+	over := overflow.Width(text, c.JSON == types.JSONOutputWaybar) - int(c.MaxWidth)
+	if over <= 0 {
+		return lyric
+	}
+
+	fitted, left := overflow.Fit(c.Overflow, lyric, runewidth.StringWidth(lyric)-over, o.lineTiming(state))
+
+	if left > 0 && global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying {
+		o.scrollTimer.Reset(time.Duration(left / playbackRate() * float64(time.Second)))
+	}
+
+	return fitted
+}
+
+// lineTiming returns the timing of the synced line at the state's index
+// at the position extrapolated from the last update, or nil if there is no such line.
+// Does NOT lock the mutexes.
+func (o *Output) lineTiming(state json.State) *overflow.Line {
+	p := &global.Player.P
+	lyricsData := &p.Song.LyricsData
+	if lyricsData.LyricsState != types.LyricsStateSynced || state.LyricIndex < 0 || state.LyricIndex >= len(lyricsData.Lyrics) {
+		return nil
+	}
+
+	position := p.Position
+	if at := o.positionAt.Load(); at != 0 && p.PlaybackStatus == mprislib.PlaybackPlaying {
+		position += time.Since(time.Unix(0, at)).Seconds() * playbackRate()
+	}
+	position -= state.TimestampOffset + lyricsData.Offset
+
+	start, end := lyricsData.Lyrics[state.LyricIndex].Time, p.Song.Duration
+	for i := state.LyricIndex + 1; i < len(lyricsData.Lyrics); i++ {
+		if lyricsData.Lyrics[i].Time > start {
+			end = lyricsData.Lyrics[i].Time
+			break
+		}
+	}
+	if end <= start {
+		return nil
+	}
+
+	return &overflow.Line{Elapsed: position - start, Length: end - start}
+}
+
+// playbackRate returns the player's rate, which is 1 if it's unknown.
+// Does NOT lock the player mutex.
+func playbackRate() float64 {
+	if global.Player.P.Rate <= 0 {
+		return 1
+	}
+	return global.Player.P.Rate
+}
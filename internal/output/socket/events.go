@@ -0,0 +1,134 @@
+package socket
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// kinds are the kinds of events in the order new clients get them in
+var kinds = []string{"player", "song", "state", "line"}
+
+// PlayerEvent is published when another player is followed
+type PlayerEvent struct {
+	Event string `json:"event"`
+	Name  string `json:"name"`
+}
+
+// SongEvent is published when the song or its lyrics change
+type SongEvent struct {
+	Event       string   `json:"event"`
+	Title       string   `json:"title"`
+	Artists     []string `json:"artists"`
+	Album       string   `json:"album"`
+	Duration    float64  `json:"duration"`
+	LyricsState string   `json:"lyrics_state"`
+}
+
+// StateEvent is published when the playback state, the position or the offset change
+type StateEvent struct {
+	Event    string  `json:"event"`
+	Status   string  `json:"status"`
+	Position float64 `json:"position"`
+	Rate     float64 `json:"rate"`
+	Offset   float64 `json:"offset"`
+}
+
+// LineEvent is published when the current lyric line changes
+type LineEvent struct {
+	Event string `json:"event"`
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// publish sends the event to every client unless it's the same as the last one of its kind.
+// A client whose queue is full is disconnected instead of waiting for it.
+func (o *Output) publish(kind string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Error("output/socket", "Error marshalling the event: "+err.Error())
+		return
+	}
+	data = append(data, '\n')
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if bytes.Equal(o.last[kind], data) {
+		return
+	}
+	o.last[kind] = data
+
+	for cl := range o.clients {
+		select {
+		case cl.queue <- data:
+		default:
+			log.Warn("output/socket", "A client doesn't keep up with the events and is disconnected")
+			o.drop(cl)
+		}
+	}
+}
+
+func (o *Output) publishPlayer() {
+	global.Player.M.Lock()
+	name := global.Player.P.Name
+	global.Player.M.Unlock()
+
+	o.publish("player", PlayerEvent{Event: "player", Name: name})
+}
+
+func (o *Output) publishSong() {
+	global.Player.M.Lock()
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+
+	artists := song.Artists
+	if artists == nil {
+		artists = []string{}
+	}
+	o.publish("song", SongEvent{
+		Event:       "song",
+		Title:       song.Title,
+		Artists:     artists,
+		Album:       song.Album,
+		Duration:    song.Duration,
+		LyricsState: song.LyricsData.LyricsState.String(),
+	})
+}
+
+func (o *Output) publishState() {
+	timestampOffset := offset.Get()
+
+	global.Player.M.Lock()
+	status := string(global.Player.P.PlaybackStatus)
+	if status == "" {
+		status = string(mprislib.PlaybackStopped)
+	}
+	e := StateEvent{
+		Event:    "state",
+		Status:   status,
+		Position: global.Player.P.Position,
+		Rate:     global.Player.P.Rate,
+		Offset:   timestampOffset + global.Player.P.Song.LyricsData.Offset,
+	}
+	global.Player.M.Unlock()
+
+	o.publish("state", e)
+}
+
+func (o *Output) publishLine(lyricIndex int) {
+	global.Player.M.Lock()
+	lyrics := global.Player.P.Song.LyricsData.Lyrics
+	global.Player.M.Unlock()
+
+	text := ""
+	if lyricIndex >= 0 && lyricIndex < len(lyrics) {
+		text = lyrics[lyricIndex].Text
+	}
+	o.publish("line", LineEvent{Event: "line", Index: lyricIndex, Text: text})
+}
@@ -0,0 +1,40 @@
+package socket
+
+import "lrcsnc/internal/pkg/global"
+
+func (o *Output) OnConfigUpdate() {
+	global.Config.M.Lock()
+	p := o.config().Path
+	global.Config.M.Unlock()
+
+	o.M.Lock()
+	if o.listener != nil && p != o.path {
+		// The old path is kept if the new one is unavailable
+		old := o.listener
+		if o.listen(p) == nil {
+			old.Close()
+		}
+	}
+	o.M.Unlock()
+
+	// The offset may have changed
+	o.publishState()
+}
+
+func (o *Output) OnPlayerUpdate() {
+	o.publishPlayer()
+	o.publishSong()
+	o.publishState()
+}
+
+func (o *Output) OnOverwrite(overwrite string) {}
+
+func (o *Output) OnPositionUpdate() {
+	o.publishState()
+}
+
+func (o *Output) DisplayLyric(lyricIndex int) {
+	o.publishLine(lyricIndex)
+	// The position is updated on every line too
+	o.publishState()
+}
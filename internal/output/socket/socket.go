@@ -0,0 +1,180 @@
+package socket
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"lrcsnc/internal/control"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+)
+
+// Every client gets newline-delimited JSON events (see events.go).
+// A client that doesn't read them fast enough to keep up with the queue
+// is disconnected, so that it never holds up the others or the lyrics.
+const (
+	queueSize    = 64
+	writeTimeout = 5 * time.Second
+)
+
+// Output publishes the events on a Unix socket to any number of clients.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
+
+	M        sync.Mutex
+	listener net.Listener
+	path     string
+	clients  map[*client]bool
+	// last are the last published events of every kind,
+	// which new clients get on connect
+	last map[string][]byte
+}
+
+type client struct {
+	conn  net.Conn
+	queue chan []byte
+}
+
+// New creates the socket output with the name and starts listening on its path.
+func New(name string) (*Output, error) {
+	o := &Output{
+		name:    name,
+		clients: make(map[*client]bool),
+		last:    make(map[string][]byte),
+	}
+
+	global.Config.M.Lock()
+	p := o.config().Path
+	global.Config.M.Unlock()
+
+	o.M.Lock()
+	err := o.listen(p)
+	o.M.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// The clients that connect before anything happens get the state as it is
+	o.OnPlayerUpdate()
+	o.publishLine(-1)
+
+	return o, nil
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.SocketOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.Socket
+}
+
+// listen starts accepting the clients on the path.
+// Must be called while holding the output's mutex.
+func (o *Output) listen(p string) error {
+	expanded := control.ExpandSocketPath(p)
+
+	// A socket file may be left over from an instance that didn't exit properly,
+	// but it may also belong to an instance that is still running
+	if _, err := os.Stat(expanded); err == nil {
+		if c, err := net.DialTimeout("unix", expanded, time.Second); err == nil {
+			c.Close()
+			log.Error("output/socket", "The event socket '"+expanded+"' is served by another instance")
+			return errs.ErrEventSocketInUse
+		}
+		log.Debug("output/socket", "Removing a stale event socket")
+		os.Remove(expanded)
+	}
+
+	l, err := net.Listen("unix", expanded)
+	if err != nil {
+		log.Error("output/socket", "Failed to listen on the event socket: "+err.Error())
+		return err
+	}
+	if err := os.Chmod(expanded, 0o600); err != nil {
+		log.Warn("output/socket", "Failed to restrict the event socket permissions: "+err.Error())
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				// The listener was closed
+				return
+			}
+			o.accept(c)
+		}
+	}()
+
+	o.listener, o.path = l, p
+	log.Info("output/socket", "Publishing the events on '"+expanded+"'")
+	return nil
+}
+
+// accept adds the client and sends it the current state
+func (o *Output) accept(c net.Conn) {
+	cl := &client{conn: c, queue: make(chan []byte, queueSize)}
+
+	o.M.Lock()
+	// The client may have been accepted while the output was closing
+	if o.listener == nil {
+		o.M.Unlock()
+		c.Close()
+		return
+	}
+	for _, kind := range kinds {
+		if data, ok := o.last[kind]; ok {
+			cl.queue <- data
+		}
+	}
+	o.clients[cl] = true
+	o.M.Unlock()
+
+	go o.serve(cl)
+}
+
+// serve writes the queued events to the client until it's gone
+func (o *Output) serve(cl *client) {
+	for data := range cl.queue {
+		cl.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := cl.conn.Write(data); err != nil {
+			log.Debug("output/socket", "A client is gone: "+err.Error())
+			o.M.Lock()
+			o.drop(cl)
+			o.M.Unlock()
+			// The rest of the queue is not needed anymore
+			for range cl.queue {
+			}
+			return
+		}
+	}
+}
+
+// drop disconnects the client.
+// Must be called while holding the output's mutex.
+func (o *Output) drop(cl *client) {
+	if !o.clients[cl] {
+		return
+	}
+	delete(o.clients, cl)
+	close(cl.queue)
+	cl.conn.Close()
+}
+
+// Close stops listening and disconnects all the clients.
+func (o *Output) Close() {
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if o.listener != nil {
+		o.listener.Close()
+		o.listener = nil
+	}
+	for cl := range o.clients {
+		o.drop(cl)
+	}
+}
@@ -0,0 +1,23 @@
+package tui
+
+type Controller struct{}
+
+func (Controller) OnConfigUpdate() {
+	send(refreshMsg{})
+}
+
+func (Controller) OnPlayerUpdate() {
+	send(refreshMsg{})
+}
+
+func (Controller) OnOverwrite(overwrite string) {
+	send(overwriteMsg(overwrite))
+}
+
+func (Controller) OnPositionUpdate() {
+	send(refreshMsg{})
+}
+
+func (Controller) DisplayLyric(lyricIndex int) {
+	send(lineMsg(lyricIndex))
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/pkg/structs"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMap is the keybindings of the TUI. It satisfies help.KeyMap.
+type keyMap struct {
+	PlayPause    key.Binding
+	SeekBack     key.Binding
+	SeekForward  key.Binding
+	PreviousLine key.Binding
+	NextLine     key.Binding
+	OffsetUp     key.Binding
+	OffsetDown   key.Binding
+	OffsetReset  key.Binding
+	Refetch      key.Binding
+	ScrollUp     key.Binding
+	ScrollDown   key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+}
+
+var keys = keyMap{
+	PlayPause: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "play/pause"),
+	),
+	SeekBack: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "seek back"),
+	),
+	SeekForward: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→/l", "seek forward"),
+	),
+	PreviousLine: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous line"),
+	),
+	NextLine: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next line"),
+	),
+	OffsetUp: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "offset up"),
+	),
+	OffsetDown: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "offset down"),
+	),
+	OffsetReset: key.NewBinding(
+		key.WithKeys("0"),
+		key.WithHelp("0", "reset offset"),
+	),
+	Refetch: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refetch"),
+	),
+	ScrollUp: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "scroll up"),
+	),
+	ScrollDown: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "scroll down"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "page up"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdn", "page down"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "more keys"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.PlayPause, k.SeekBack, k.SeekForward, k.OffsetUp, k.OffsetDown, k.Help, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.PlayPause, k.SeekBack, k.SeekForward, k.PreviousLine, k.NextLine},
+		{k.OffsetUp, k.OffsetDown, k.OffsetReset, k.Refetch},
+		{k.ScrollUp, k.ScrollDown, k.PageUp, k.PageDown},
+		{k.Help, k.Quit},
+	}
+}
+
+// KeyCommand returns the control command (with its data) that the key sends,
+// or false if the key is not bound to a command (e.g. it scrolls the lyrics)
+func KeyCommand(msg tea.KeyMsg, c structs.TUIOutputConfig) (control.CommandType, any, bool) {
+	switch {
+	case key.Matches(msg, keys.PlayPause):
+		return control.CommandPlayer, control.PlayerPlayPause, true
+	case key.Matches(msg, keys.SeekBack):
+		return control.CommandSeekRelative, -c.SeekStep, true
+	case key.Matches(msg, keys.SeekForward):
+		return control.CommandSeekRelative, c.SeekStep, true
+	case key.Matches(msg, keys.PreviousLine):
+		return control.CommandSeekRelativeLine, -1, true
+	case key.Matches(msg, keys.NextLine):
+		return control.CommandSeekRelativeLine, 1, true
+	case key.Matches(msg, keys.OffsetUp):
+		return control.CommandAdjustOffset, c.OffsetStep, true
+	case key.Matches(msg, keys.OffsetDown):
+		return control.CommandAdjustOffset, -c.OffsetStep, true
+	case key.Matches(msg, keys.OffsetReset):
+		return control.CommandResetOffset, nil, true
+	case key.Matches(msg, keys.Refetch):
+		return control.CommandRefetch, nil, true
+	default:
+		return 0, nil, false
+	}
+}
@@ -0,0 +1,344 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The messages the model gets from the controller and itself
+type (
+	// lineMsg makes the lyric line with the index current
+	lineMsg int
+	// refreshMsg makes the model read the player's data and the config again
+	refreshMsg struct{}
+	// overwriteMsg is shown in place of the status line for a while
+	overwriteMsg string
+	// tickMsg redraws the progress bar
+	tickMsg time.Time
+	// resultMsg is the outcome of a control command sent by a keybinding
+	resultMsg struct{ err error }
+)
+
+const (
+	tickInterval      = 250 * time.Millisecond
+	overwriteDuration = 5 * time.Second
+	// chromeHeight is the height of everything around the lyrics except the help
+	chromeHeight = 7
+)
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	dimStyle     = lipgloss.NewStyle().Faint(true)
+	currentStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+type model struct {
+	width, height int
+
+	lyrics   viewport.Model
+	progress progress.Model
+	help     help.Model
+
+	// The player's data as of the last refresh
+	song           structs.Song
+	player         string
+	playbackStatus mprislib.PlaybackStatus
+	position       float64
+	positionAt     time.Time
+	rate           float64
+	offset         float64
+
+	config structs.TUIOutputConfig
+	// index is the current lyric line
+	index int
+
+	message      string
+	messageError bool
+	messageUntil time.Time
+}
+
+func newModel() model {
+	m := model{
+		lyrics:   viewport.New(0, 0),
+		progress: progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage()),
+		help:     help.New(),
+		index:    -1,
+	}
+	m.refresh()
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+	case lineMsg:
+		m.index = int(msg)
+		m.refresh()
+	case refreshMsg:
+		m.refresh()
+	case overwriteMsg:
+		m.showMessage(string(msg), false)
+		return m, nil
+	case resultMsg:
+		if msg.err != nil {
+			m.showMessage("Error: "+msg.err.Error(), true)
+		}
+		return m, nil
+	case tickMsg:
+		return m, tick()
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	default:
+		return m, nil
+	}
+
+	m.renderLyrics()
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if t, data, ok := KeyCommand(msg, m.config); ok {
+		return m, sendCommand(t, data)
+	}
+
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, keys.Help):
+		m.help.ShowAll = !m.help.ShowAll
+		m.resize()
+		m.renderLyrics()
+	// Synced lyrics can be scrolled too, but they return to the current line when it changes
+	case key.Matches(msg, keys.ScrollUp):
+		m.lyrics.LineUp(1)
+	case key.Matches(msg, keys.ScrollDown):
+		m.lyrics.LineDown(1)
+	case key.Matches(msg, keys.PageUp):
+		m.lyrics.ViewUp()
+	case key.Matches(msg, keys.PageDown):
+		m.lyrics.ViewDown()
+	}
+
+	return m, nil
+}
+
+func (m model) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	if m.playbackStatus == mprislib.PlaybackStopped || m.playbackStatus == "" {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dimStyle.Render("Nothing is playing"))
+	}
+
+	var b strings.Builder
+
+	// Song metadata
+	b.WriteString(titleStyle.Render(truncate(m.song.Title, m.width)) + "\n")
+	details := strings.Join(m.song.Artists, ", ")
+	if m.song.Album != "" {
+		details += " — " + m.song.Album
+	}
+	b.WriteString(truncate(details, m.width) + "\n")
+	state := fmt.Sprintf("%s · %s · %s lyrics · offset %+.2fs", m.player, strings.ToLower(string(m.playbackStatus)), m.song.LyricsData.LyricsState, m.offset)
+	if m.song.LyricsData.Offset != 0 {
+		state += fmt.Sprintf(" (song %+.2fs)", m.song.LyricsData.Offset)
+	}
+	b.WriteString(dimStyle.Render(truncate(state, m.width)) + "\n\n")
+
+	b.WriteString(m.lyrics.View() + "\n\n")
+
+	// Progress
+	position := m.currentPosition()
+	elapsed, total := formatTime(position), formatTime(m.song.Duration)
+	percent := 0.0
+	if m.song.Duration > 0 {
+		percent = min(position/m.song.Duration, 1)
+	}
+	b.WriteString(elapsed + " " + m.progress.ViewAs(percent) + " " + total + "\n")
+
+	// A message or the help
+	if time.Now().Before(m.messageUntil) {
+		if m.messageError {
+			b.WriteString(errorStyle.Render(truncate(m.message, m.width)))
+		} else {
+			b.WriteString(truncate(m.message, m.width))
+		}
+	}
+	b.WriteString("\n" + m.help.View(keys))
+
+	return b.String()
+}
+
+// refresh reads the player's data and the config again
+func (m *model) refresh() {
+	m.offset = offset.Get()
+
+	global.Config.M.Lock()
+	if instance, ok := global.Config.C.Output.Instance(name); ok {
+		m.config = instance.TUI
+	}
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	m.song = global.Player.P.Song
+	m.player = global.Player.P.Name
+	m.playbackStatus = global.Player.P.PlaybackStatus
+	m.position = global.Player.P.Position
+	m.rate = global.Player.P.Rate
+	global.Player.M.Unlock()
+
+	m.positionAt = time.Now()
+}
+
+// resize fits the lyrics and the progress bar into the window
+func (m *model) resize() {
+	m.help.Width = m.width
+	m.lyrics.Width = m.width
+	m.lyrics.Height = max(m.height-chromeHeight-lipgloss.Height(m.help.View(keys)), 1)
+	// The times around the bar take up to 6 cells each
+	m.progress.Width = max(m.width-14, 1)
+}
+
+// renderLyrics puts the lyrics into the viewport.
+// Synced lyrics are scrolled to have the current line in the middle.
+func (m *model) renderLyrics() {
+	if m.width == 0 {
+		return
+	}
+
+	line := lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center)
+	lyricsData := m.song.LyricsData
+
+	switch lyricsData.LyricsState {
+	case types.LyricsStateSynced:
+		var b strings.Builder
+		y, currentY, currentHeight := 0, 0, 0
+		for i, lyric := range lyricsData.Lyrics {
+			text := lyric.Text
+			if strings.TrimSpace(text) == "" {
+				text = "♪"
+			}
+
+			var rendered string
+			switch {
+			case i == m.index:
+				rendered = line.Inherit(currentStyle).Render(text)
+				currentY, currentHeight = y, lipgloss.Height(rendered)
+			case i < m.index:
+				rendered = line.Inherit(dimStyle).Render(text)
+			default:
+				rendered = line.Render(text)
+			}
+
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(rendered)
+			y += lipgloss.Height(rendered)
+		}
+		m.lyrics.SetContent(b.String())
+
+		switch {
+		case m.index < 0:
+			m.lyrics.GotoTop()
+		case m.index >= len(lyricsData.Lyrics):
+			m.lyrics.GotoBottom()
+		default:
+			m.lyrics.SetYOffset(currentY + currentHeight/2 - m.lyrics.Height/2)
+		}
+	case types.LyricsStatePlain:
+		lines := make([]string, len(lyricsData.Lyrics))
+		for i, lyric := range lyricsData.Lyrics {
+			lines[i] = line.Render(lyric.Text)
+		}
+		m.lyrics.SetContent(strings.Join(lines, "\n"))
+	default:
+		m.lyrics.SetContent(lipgloss.Place(m.width, m.lyrics.Height, lipgloss.Center, lipgloss.Center, dimStyle.Render(stateMessage(lyricsData.LyricsState))))
+		m.lyrics.GotoTop()
+	}
+}
+
+// currentPosition extrapolates the position from the last refresh
+func (m model) currentPosition() float64 {
+	position := m.position
+	if m.playbackStatus == mprislib.PlaybackPlaying {
+		position += time.Since(m.positionAt).Seconds() * m.rate
+	}
+	if m.song.Duration > 0 {
+		position = min(position, m.song.Duration)
+	}
+	return max(position, 0)
+}
+
+func (m *model) showMessage(message string, isError bool) {
+	m.message = message
+	m.messageError = isError
+	m.messageUntil = time.Now().Add(overwriteDuration)
+}
+
+func stateMessage(state types.LyricsState) string {
+	switch state {
+	case types.LyricsStateInstrumental:
+		return "♪ Instrumental ♪"
+	case types.LyricsStateNotFound:
+		return "No lyrics were found for this song"
+	case types.LyricsStateLoading:
+		return "Loading lyrics..."
+	default:
+		return "Couldn't get the lyrics"
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// sendCommand sends the control command outside of the TUI's loop
+// and reports the outcome back to it
+func sendCommand(t control.CommandType, data any) tea.Cmd {
+	return func() tea.Msg {
+		_, err := control.Send(t, data)
+		return resultMsg{err}
+	}
+}
+
+func formatTime(seconds float64) string {
+	return fmt.Sprintf("%d:%02d", int(seconds)/60, int(seconds)%60)
+}
+
+// truncate cuts the string to fit into the width
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"sync/atomic"
+
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/pkg/log"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var program *tea.Program
+
+// done is closed once the program has exited and the terminal is restored
+var done chan bool
+
+// closing tells that the TUI is stopped by the app and not by the user
+var closing atomic.Bool
+
+// name is the name of the output, which its settings are found by
+var name string
+
+// Init starts the full-screen terminal UI as the output with the name.
+func Init(outputName string) {
+	name = outputName
+	closing.Store(false)
+	program = tea.NewProgram(newModel(),
+		tea.WithAltScreen(),
+		// The app handles the signals itself and closes the TUI on exit
+		tea.WithoutSignalHandler(),
+	)
+	done = make(chan bool)
+
+	go func() {
+		defer close(done)
+		if _, err := program.Run(); err != nil {
+			log.Error("output/tui", "The terminal UI has stopped: "+err.Error())
+		}
+		// Quitting the TUI quits the app, the same way as the quit command
+		if !closing.Load() {
+			_, _ = control.Send(control.CommandQuit, nil)
+		}
+	}()
+}
+
+// Close stops the TUI and restores the terminal.
+func Close() {
+	if program == nil {
+		return
+	}
+
+	closing.Store(true)
+	program.Quit()
+	<-done
+}
+
+// send passes the message to the TUI if it's running
+func send(msg any) {
+	if program == nil {
+		return
+	}
+
+	program.Send(msg)
+}
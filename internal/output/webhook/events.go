@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"time"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// Payload is the body of every request
+type Payload struct {
+	Events []Event `json:"events"`
+}
+
+// Event is a single song, line or lyrics state change
+type Event struct {
+	Event       types.WebhookEventType `json:"event"`
+	Time        time.Time              `json:"time"`
+	Player      string                 `json:"player"`
+	Title       string                 `json:"title"`
+	Artists     []string               `json:"artists"`
+	Album       string                 `json:"album"`
+	LyricsState string                 `json:"lyrics_state"`
+	// Line is only set for the line events
+	Line *Line `json:"line,omitempty"`
+}
+
+// Line is the line that started. The index is -1 before the first line
+type Line struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// newEvent creates the event of the kind with the player's and song's data
+func newEvent(kind types.WebhookEventType, player string, song structs.Song) Event {
+	return Event{
+		Event:       kind,
+		Time:        time.Now(),
+		Player:      player,
+		Title:       song.Title,
+		Artists:     song.Artists,
+		Album:       song.Album,
+		LyricsState: song.LyricsData.LyricsState.String(),
+	}
+}
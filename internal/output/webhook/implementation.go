@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/types"
+)
+
+func (o *Output) OnConfigUpdate() {}
+
+func (o *Output) OnPlayerUpdate() {
+	global.Player.M.Lock()
+	player, song := global.Player.P.Name, global.Player.P.Song
+	global.Player.M.Unlock()
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	if id := song.ID(); id != o.songID {
+		o.songID = id
+		o.lyricsState = song.LyricsData.LyricsState
+		o.lineIndex, o.lineText = -1, ""
+		o.enqueue(newEvent(types.WebhookSong, player, song))
+		return
+	}
+
+	if song.LyricsData.LyricsState != o.lyricsState {
+		o.lyricsState = song.LyricsData.LyricsState
+		o.enqueue(newEvent(types.WebhookLyricsState, player, song))
+	}
+}
+
+func (o *Output) OnOverwrite(overwrite string) {}
+
+func (o *Output) OnPositionUpdate() {}
+
+func (o *Output) DisplayLyric(lyricIndex int) {
+	global.Player.M.Lock()
+	player, song := global.Player.P.Name, global.Player.P.Song
+	global.Player.M.Unlock()
+
+	var text string
+	if lyricIndex >= 0 && lyricIndex < len(song.LyricsData.Lyrics) {
+		text = song.LyricsData.Lyrics[lyricIndex].Text
+	} else {
+		lyricIndex = -1
+	}
+
+	o.M.Lock()
+	defer o.M.Unlock()
+
+	// The same line is displayed again after seeks and reloads
+	if lyricIndex == o.lineIndex && text == o.lineText {
+		return
+	}
+	o.lineIndex, o.lineText = lyricIndex, text
+
+	e := newEvent(types.WebhookLine, player, song)
+	e.Line = &Line{Index: lyricIndex, Text: text}
+	o.enqueue(e)
+}
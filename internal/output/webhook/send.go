@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"lrcsnc/internal/pkg/structs"
+)
+
+// Send POSTs the events to every URL in the config, retrying the failed requests.
+// The returned error is the one of the last URL that failed.
+func Send(c structs.WebhookOutputConfig, events []Event) error {
+	return send(c, events, nil)
+}
+
+// send is Send that gives up waiting for a retry once done is closed
+func send(c structs.WebhookOutputConfig, events []Event, done <-chan bool) error {
+	body, err := json.Marshal(Payload{Events: events})
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, url := range c.URLs {
+		if err := post(c, url, body, done); err != nil {
+			lastErr = fmt.Errorf("%s: %w", url, err)
+		}
+	}
+
+	return lastErr
+}
+
+// post makes the request to the URL, retrying it on network errors, server errors and rate limits.
+// The delay before every retry is twice as long as the previous one
+func post(c structs.WebhookOutputConfig, url string, body []byte, done <-chan bool) error {
+	delay := time.Duration(c.RetryDelay * float64(time.Second))
+
+	var err error
+	for attempt := uint(0); ; attempt++ {
+		var retry bool
+		if retry, err = request(c, url, body); err == nil || !retry || attempt >= c.Retries {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-done:
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// request makes a single request, reporting whether it's worth retrying if it failed
+func request(c structs.WebhookOutputConfig, url string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout*float64(time.Second)))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lrcsnc")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusRequestTimeout:
+		return true, fmt.Errorf("the server responded with '%s'", resp.Status)
+	default:
+		return false, fmt.Errorf("the server responded with '%s'", resp.Status)
+	}
+}
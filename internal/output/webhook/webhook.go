@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// queueSize is how many events may wait to be sent before the new ones are dropped
+const queueSize = 256
+
+// Output POSTs the song, line and lyrics state events as JSON to the configured URLs.
+// Every output has its own name, which its settings are found by.
+type Output struct {
+	name string
+
+	queue chan Event
+	done  chan bool
+
+	// The last sent state, not to send the same events again
+	M           sync.Mutex
+	songID      uint64
+	lyricsState types.LyricsState
+	lineIndex   int
+	lineText    string
+}
+
+// New creates the webhook output with the name.
+func New(name string) *Output {
+	o := &Output{
+		name:        name,
+		queue:       make(chan Event, queueSize),
+		done:        make(chan bool),
+		lyricsState: types.LyricsStateUnknown,
+		lineIndex:   -1,
+	}
+	go o.worker()
+
+	return o
+}
+
+// config returns the settings of the output.
+// Does NOT lock the config mutex.
+func (o *Output) config() *structs.WebhookOutputConfig {
+	instance, _ := global.Config.C.Output.Instance(o.name)
+	return &instance.Webhook
+}
+
+// enqueue puts the event in the queue if its kind is one to be sent.
+// The event is dropped if the queue is full, so that a slow endpoint doesn't hold up the lyrics
+func (o *Output) enqueue(e Event) {
+	global.Config.M.Lock()
+	events := o.config().Events
+	global.Config.M.Unlock()
+
+	if len(events) != 0 && !slices.Contains(events, e.Event) {
+		return
+	}
+
+	select {
+	case o.queue <- e:
+	default:
+		log.Warn("output/webhook", "The event queue is full, dropping a '"+string(e.Event)+"' event")
+	}
+}
+
+// worker collects the queued events into batches and sends them
+func (o *Output) worker() {
+	var batch []Event
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	for {
+		select {
+		case e := <-o.queue:
+			batch = append(batch, e)
+
+			global.Config.M.Lock()
+			c := *o.config()
+			global.Config.M.Unlock()
+
+			if c.Batch <= 0 {
+				o.flush(c, batch)
+				batch = nil
+			} else if timeout == nil {
+				timer = time.NewTimer(time.Duration(c.Batch * float64(time.Second)))
+				timeout = timer.C
+			}
+		case <-timeout:
+			global.Config.M.Lock()
+			c := *o.config()
+			global.Config.M.Unlock()
+
+			o.flush(c, batch)
+			batch, timeout = nil, nil
+		case <-o.done:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
+
+// flush sends the batch, reporting the failure
+func (o *Output) flush(c structs.WebhookOutputConfig, batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := send(c, batch, o.done); err != nil {
+		log.Warn("output/webhook", "Failed to send the events: "+err.Error())
+	}
+}
+
+// Close stops the output. The events that are not sent yet are dropped.
+func (o *Output) Close() {
+	close(o.done)
+}
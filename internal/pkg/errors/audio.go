@@ -0,0 +1,6 @@
+package errors
+
+import "errors"
+
+// ErrNoDefaultSink is returned when the sound server doesn't report a default sink
+var ErrNoDefaultSink = errors.New("no default audio sink")
@@ -0,0 +1,6 @@
+package errors
+
+import "errors"
+
+// ErrCacheDisabled is returned when something has to be stored in the cache while it's disabled
+var ErrCacheDisabled = errors.New("cache is disabled")
@@ -0,0 +1,27 @@
+package errors
+
+import "errors"
+
+// ErrUnknownCommand is returned when a control command is not recognized
+var ErrUnknownCommand = errors.New("unknown command")
+
+// ErrInvalidCommandData is returned when a control command has malformed or missing arguments
+var ErrInvalidCommandData = errors.New("invalid command arguments")
+
+// ErrNothingPlaying is returned when a control command requires an active song, but there is none
+var ErrNothingPlaying = errors.New("nothing is playing")
+
+// ErrSocketInUse is returned when the control socket is already served by another instance
+var ErrSocketInUse = errors.New("the control socket is already in use")
+
+// ErrNoSyncedLyrics is returned when a control command requires synced lyrics, but the current song doesn't have them
+var ErrNoSyncedLyrics = errors.New("the current song has no synced lyrics")
+
+// ErrLineNotFound is returned when the requested lyric line doesn't exist
+var ErrLineNotFound = errors.New("the lyric line was not found")
+
+// ErrRateUnsupported is returned when the player doesn't support the requested playback rate
+var ErrRateUnsupported = errors.New("the player doesn't support the requested playback rate")
+
+// ErrEmptyLoop is returned when a practice loop would end where it starts
+var ErrEmptyLoop = errors.New("the practice loop would end where it starts")
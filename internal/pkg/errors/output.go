@@ -0,0 +1,12 @@
+package errors
+
+import "errors"
+
+// ErrDBusNameTaken is returned when the D-Bus service name is already owned by someone else (most likely another instance)
+var ErrDBusNameTaken = errors.New("the D-Bus service name is already taken")
+
+// ErrEventSocketInUse is returned when the event socket is already served by another instance
+var ErrEventSocketInUse = errors.New("the event socket is already in use")
+
+// ErrUnknownOutputType is returned when an output of an unknown type is to be started
+var ErrUnknownOutputType = errors.New("unknown output type")
@@ -17,18 +17,22 @@ type Config struct {
 	Cache CacheConfig `toml:"cache"`
 	// Output config has... a lot of personalized settings.
 	Output OutputConfig `toml:"output"`
+	// Control config is for the control socket used by `lrcsnc ctl`
+	Control ControlConfig `toml:"control"`
 }
 
 // LEVEL 1
 
 type PlayerConfig struct {
-	IncludedPlayers []string `toml:"included-players"`
-	ExcludedPlayers []string `toml:"excluded-players"`
+	IncludedPlayers []string              `toml:"included-players"`
+	ExcludedPlayers []string              `toml:"excluded-players"`
+	DriftCorrection DriftCorrectionConfig `toml:"drift-correction"`
 }
 
 type LyricsConfig struct {
 	Provider        types.LyricsProviderType `toml:"provider"`
 	TimestampOffset float64                  `toml:"timestamp-offset"`
+	OffsetProfiles  OffsetProfilesConfig     `toml:"offset-profiles"`
 	Romanization    RomanizationConfig       `toml:"romanization"`
 }
 
@@ -40,12 +44,154 @@ type CacheConfig struct {
 }
 
 type OutputConfig struct {
-	Type  types.OutputType  `toml:"type"`
-	Piped PipedOutputConfig `toml:"piped"`
+	// The type and the settings of every type ([output.<type>]) make up the single output
+	// used when no instances are configured
+	OutputInstanceConfig
+	// TickInterval is how often (in seconds) the output gets the updated position
+	// while playing, e.g. to show progress. 0 means only on line changes
+	TickInterval float64     `toml:"tick-interval"`
+	Intro        IntroConfig `toml:"intro"`
+	Outro        OutroConfig `toml:"outro"`
+	// Instances are the named outputs that are all used at the same time.
+	// If there are none, a single output is made of the type and its settings above
+	Instances map[string]*OutputInstanceConfig `toml:"instances"`
+}
+
+// DefaultOutputInstance is the name of the single output
+// that is used when no instances are configured
+const DefaultOutputInstance = "default"
+
+// Outputs returns the configured outputs by their names
+func (c *OutputConfig) Outputs() map[string]*OutputInstanceConfig {
+	if len(c.Instances) > 0 {
+		return c.Instances
+	}
+	return map[string]*OutputInstanceConfig{DefaultOutputInstance: &c.OutputInstanceConfig}
+}
+
+// Instance returns the settings of the output with the name.
+// It's called on every output's update, so nothing is copied:
+// the settings belong to the config and are only valid under its mutex.
+// If there is no such output (e.g. it's just been removed), the settings are empty.
+func (c *OutputConfig) Instance(name string) (*OutputInstanceConfig, bool) {
+	if len(c.Instances) == 0 {
+		if name == DefaultOutputInstance {
+			return &c.OutputInstanceConfig, true
+		}
+		return &OutputInstanceConfig{}, false
+	}
+	if instance, ok := c.Instances[name]; ok {
+		return instance, true
+	}
+	return &OutputInstanceConfig{}, false
+}
+
+type OutputInstanceConfig struct {
+	Type types.OutputType `toml:"type"`
+	// The settings of every type fall back to the ones in [output.<type>]
+	// for everything that is not set for the instance
+	Piped   PipedOutputConfig   `toml:"piped"`
+	TUI     TUIOutputConfig     `toml:"tui"`
+	HTTP    HTTPOutputConfig    `toml:"http"`
+	Socket  SocketOutputConfig  `toml:"socket"`
+	Notify  NotifyOutputConfig  `toml:"notify"`
+	MPV     MPVOutputConfig     `toml:"mpv"`
+	Webhook WebhookOutputConfig `toml:"webhook"`
+}
+
+type TUIOutputConfig struct {
+	// SeekStep is how far (in seconds) the seek keys go
+	SeekStep float64 `toml:"seek-step"`
+	// OffsetStep is how much (in seconds) the offset keys shift the offset
+	OffsetStep float64 `toml:"offset-step"`
+}
+
+type HTTPOutputConfig struct {
+	// Address is the host and port to serve the page, the snapshot and the stream on
+	Address string              `toml:"address"`
+	Context ContextOutputConfig `toml:"context"`
+	Theme   HTTPThemeConfig     `toml:"theme"`
+}
+
+type SocketOutputConfig struct {
+	// Path is the Unix socket the events are published on
+	Path string `toml:"path"`
+}
+
+type NotifyOutputConfig struct {
+	Mode types.NotifyMode `toml:"mode"`
+	// Lines is how many first lines are shown on a song change in the "song" mode
+	Lines   uint                `toml:"lines"`
+	Summary string              `toml:"summary"`
+	Urgency types.NotifyUrgency `toml:"urgency"`
+	// Timeout is how long (in seconds) a notification is shown.
+	// 0 means until it's closed, and a negative one leaves it to the notification server
+	Timeout float64 `toml:"timeout"`
+}
+
+type MPVOutputConfig struct {
+	// Socket is the path of mpv's --input-ipc-server
+	Socket string `toml:"socket"`
+	// Player is the part of the player's name that the lines are shown for
+	Player string `toml:"player"`
+	Format string `toml:"format"`
+	// Duration is how long (in seconds) the last line is shown, as well as any line while paused
+	Duration float64 `toml:"duration"`
+}
+
+type WebhookOutputConfig struct {
+	URLs []string `toml:"urls"`
+	// Events are the kinds of events that are sent. If there are none, all of them are
+	Events  []types.WebhookEventType `toml:"events"`
+	Headers map[string]string        `toml:"headers"`
+	// Batch is how long (in seconds) the events are collected to be sent together.
+	// 0 sends every event right away
+	Batch float64 `toml:"batch"`
+	// Timeout is how long (in seconds) a request may take
+	Timeout float64 `toml:"timeout"`
+	// Retries is how many more times a failed request is made
+	Retries uint `toml:"retries"`
+	// RetryDelay is how long (in seconds) to wait before the first retry.
+	// Every next one waits twice as long
+	RetryDelay float64 `toml:"retry-delay"`
+}
+
+type IntroConfig struct {
+	Behaviour types.IntroBehaviour `toml:"behaviour"`
+	// Format is used by the "title" behaviour
+	Format string `toml:"format"`
+}
+
+type OutroConfig struct {
+	Behaviour types.OutroBehaviour `toml:"behaviour"`
+	// After is how long (in seconds) the last line stays before the outro starts
+	After float64 `toml:"after"`
+	// Text is used by the "end" behaviour
+	Text string `toml:"text"`
+}
+
+type ControlConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Socket  string `toml:"socket"`
 }
 
 // LEVEL 2
 
+type DriftCorrectionConfig struct {
+	Enabled   bool    `toml:"enabled"`
+	Interval  float64 `toml:"interval"`
+	Threshold float64 `toml:"threshold"`
+}
+
+type OffsetProfilesConfig struct {
+	// Players and Sinks map the parts of player identities and audio sink names
+	// to the offsets stacked on top of the timestamp offset
+	Players    map[string]float64 `toml:"players"`
+	Sinks      map[string]float64 `toml:"sinks"`
+	DetectSink bool               `toml:"detect-sink"`
+	StateFile  string             `toml:"state-file"`
+}
+
 type RomanizationConfig struct {
 	Japanese bool `toml:"japanese"`
 	Chinese  bool `toml:"chinese"`
@@ -60,6 +206,7 @@ type PipedOutputConfig struct {
 	Destination    string                 `toml:"destination"`
 	JSON           types.JSONOutputType   `toml:"json"`
 	JSONWaybar     JSONWaybarOutputConfig `toml:"json-waybar"`
+	JSONI3bar      JSONI3barOutputConfig  `toml:"json-i3bar"`
 	InsertNewline  bool                   `toml:"insert-newline"`
 	Text           FormatOutputConfig     `toml:"text"`
 	Multiplier     FormatOutputConfig     `toml:"multiplier"`
@@ -70,14 +217,45 @@ type PipedOutputConfig struct {
 	LoadingLyrics  MessageOutputConfig    `toml:"loading-lyrics"`
 	ErrorMessage   MessageOutputConfig    `toml:"error-message"`
 	Instrumental   InstrumentalConfig     `toml:"instrumental"`
+	Context        ContextOutputConfig    `toml:"context"`
+	// MaxWidth is how many display cells the text of a lyric line may take (0 is unlimited)
+	MaxWidth uint           `toml:"max-width"`
+	Overflow OverflowConfig `toml:"overflow"`
 }
 
 // LEVEL 3
 
 type JSONWaybarOutputConfig struct {
-	Alt     string `toml:"alt"`
-	Tooltip string `toml:"tooltip"`
-	Class   string `toml:"class"`
+	Alt        string             `toml:"alt"`
+	Tooltip    string             `toml:"tooltip"`
+	Class      string             `toml:"class"`
+	Percentage types.ProgressType `toml:"percentage"`
+	Markup     WaybarMarkupConfig `toml:"markup"`
+}
+
+// WaybarMarkupConfig are the Pango markup templates that wrap the parts of the text in place of {value}.
+// An empty template leaves its part as it is
+type WaybarMarkupConfig struct {
+	Icon       string `toml:"icon"`
+	Lyric      string `toml:"lyric"`
+	Multiplier string `toml:"multiplier"`
+	// Message is used for the lyrics state messages (not found, loading, etc.), the not playing text and the outro
+	Message string `toml:"message"`
+}
+
+type JSONI3barOutputConfig struct {
+	// Name is the block's name, which the click events are matched by
+	Name string `toml:"name"`
+	// ClickEvents makes the bar send the clicks on the block to stdin
+	ClickEvents bool `toml:"click-events"`
+	// Colors are the text colors (#RRGGBB) for the lyrics states ("synced", "plain", "instrumental",
+	// "not-found", "loading" and "unknown"). The bar's own color is used for the rest
+	Colors map[string]string `toml:"colors"`
+	// Urgent are the lyrics states the block is marked urgent in
+	Urgent []string `toml:"urgent"`
+	// Clicks are the commands (as in `lrcsnc ctl`) run on the click of a mouse button:
+	// "1" is left, "2" is middle, "3" is right, "4" and "5" are scrolling up and down
+	Clicks map[string]string `toml:"clicks"`
 }
 
 type FormatOutputConfig struct {
@@ -98,8 +276,47 @@ type MessageOutputConfig struct {
 	Text    string `toml:"text"`
 }
 
+type ContextOutputConfig struct {
+	// Previous and Next are how many lines around the current one are given
+	// in JSON arrays and in the {context} placeholder
+	Previous uint `toml:"previous"`
+	Next     uint `toml:"next"`
+}
+
+type OverflowConfig struct {
+	// Mode is what is done with the lyrics that don't fit into the max width
+	Mode     types.OverflowMode `toml:"mode"`
+	Ellipsis string             `toml:"ellipsis"`
+	// Speed is how many cells per second the marquee scrolls at the least.
+	// It scrolls faster if needed to show the whole line before the next one starts
+	Speed float64 `toml:"speed"`
+	// Delay is how long (in seconds) the marquee stays at the start and the end of the line
+	Delay float64 `toml:"delay"`
+}
+
+type HTTPThemeConfig struct {
+	Font       string `toml:"font"`
+	FontSize   string `toml:"font-size"`
+	Color      string `toml:"color"`
+	DimColor   string `toml:"dim-color"`
+	Background string `toml:"background"`
+	Align      string `toml:"align"`
+	// Transition is how long (in seconds) a line takes to fade in
+	Transition float64 `toml:"transition"`
+	// Stylesheet is the path to a CSS file applied on top of the theme
+	Stylesheet string `toml:"stylesheet"`
+}
+
 type InstrumentalConfig struct {
-	Interval   float64 `toml:"interval"`
-	Symbol     string  `toml:"symbol"`
-	MaxSymbols uint    `toml:"max-symbols"`
+	Interval   float64         `toml:"interval"`
+	Symbol     string          `toml:"symbol"`
+	MaxSymbols uint            `toml:"max-symbols"`
+	Countdown  CountdownConfig `toml:"countdown"`
+}
+
+type CountdownConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Threshold is the least length (in seconds) of the instrumental gap to count down
+	Threshold float64 `toml:"threshold"`
+	Format    string  `toml:"format"`
 }
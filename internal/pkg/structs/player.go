@@ -3,6 +3,7 @@ package structs
 import (
 	"hash/fnv"
 	"lrcsnc/internal/pkg/types"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -18,16 +19,21 @@ type Player struct {
 }
 
 type Song struct {
-	Title      string
-	Artists    []string
-	Album      string
-	Duration   float64
+	Title    string
+	Artists  []string
+	Album    string
+	Duration float64
+	// ArtURL is the URL of the album art (mpris:artUrl), usually a file:// one
+	ArtURL     string
 	LyricsData LyricsData
 }
 
 type LyricsData struct {
 	Lyrics      []Lyric
 	LyricsState types.LyricsState
+	// Offset is the song's own timestamp correction in seconds
+	// that is stacked on top of the effective timestamp offset
+	Offset float64
 }
 
 type Lyric struct {
@@ -35,6 +41,63 @@ type Lyric struct {
 	Text string
 }
 
+// Surrounding returns up to `previous` non-empty lines before the line with the index
+// and up to `next` non-empty lines after it, both in the order they are sung.
+// The index may be -1 for the time before the first line.
+// Only synced lyrics have an order to speak of, so for the others there are none.
+func (l *LyricsData) Surrounding(index int, previous int, next int) (before []string, after []string) {
+	before, after = make([]string, 0, previous), make([]string, 0, next)
+	if l.LyricsState != types.LyricsStateSynced {
+		return
+	}
+
+	for i := index - 1; i >= 0 && i < len(l.Lyrics) && len(before) < previous; i-- {
+		if strings.TrimSpace(l.Lyrics[i].Text) != "" {
+			before = append(before, l.Lyrics[i].Text)
+		}
+	}
+	slices.Reverse(before)
+
+	for i := max(index+1, 0); i < len(l.Lyrics) && len(after) < next; i++ {
+		if strings.TrimSpace(l.Lyrics[i].Text) != "" {
+			after = append(after, l.Lyrics[i].Text)
+		}
+	}
+
+	return
+}
+
+// LineProgress returns how much of the line with the index has passed
+// at the position (already shifted by the timestamp offset), from 0 to 1.
+// The line lasts until the next line with a later timestamp or the end of the song.
+func (l *LyricsData) LineProgress(index int, position float64, duration float64) float64 {
+	if l.LyricsState != types.LyricsStateSynced || index < 0 || index >= len(l.Lyrics) {
+		return 0
+	}
+
+	start, end := l.Lyrics[index].Time, duration
+	for i := index + 1; i < len(l.Lyrics); i++ {
+		if l.Lyrics[i].Time > start {
+			end = l.Lyrics[i].Time
+			break
+		}
+	}
+	if end <= start {
+		return 1
+	}
+
+	return min(max((position-start)/(end-start), 0), 1)
+}
+
+// Progress returns how much of the song has been played, from 0 to 1.
+func (p *Player) Progress() float64 {
+	if p.Song.Duration <= 0 {
+		return 0
+	}
+
+	return min(max(p.Position/p.Song.Duration, 0), 1)
+}
+
 func (s *Song) ID() uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(s.Title))
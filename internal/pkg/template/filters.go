@@ -0,0 +1,142 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// filter is a filter with its arguments, as it's used in a template
+type filter struct {
+	name  string
+	args  []string
+	apply func(value string, args []string, vars Vars) string
+}
+
+// filterKind describes a filter that can be used in the templates
+type filterKind struct {
+	// minArgs and maxArgs are how many arguments the filter takes.
+	// The last argument takes the rest of the text, colons included
+	minArgs, maxArgs int
+	// check checks the arguments when the template is parsed
+	check func(args []string) error
+	apply func(value string, args []string, vars Vars) string
+}
+
+// filters are the available filters:
+//   - upper, lower and title change the case (title capitalizes every word);
+//   - trunc:N[:ellipsis] cuts the value to N columns including the ellipsis ("…" by default);
+//   - pad:N, lpad:N and center:N pad the value with spaces to N columns on the right, on the left and on both sides.
+//     The widths are the ones in a terminal, so a CJK character takes two columns;
+//   - default:text is the text if the value is empty;
+//   - or:name is the other variable's value if the value is empty.
+var filters = map[string]filterKind{
+	"upper": {apply: func(v string, _ []string, _ Vars) string { return strings.ToUpper(v) }},
+	"lower": {apply: func(v string, _ []string, _ Vars) string { return strings.ToLower(v) }},
+	"title": {apply: func(v string, _ []string, _ Vars) string { return toTitle(v) }},
+	"trunc": {minArgs: 1, maxArgs: 2, check: checkWidth, apply: truncate},
+	"pad": {minArgs: 1, maxArgs: 1, check: checkWidth, apply: func(v string, args []string, _ Vars) string {
+		n, _ := strconv.Atoi(args[0])
+		return v + strings.Repeat(" ", max(0, n-runewidth.StringWidth(v)))
+	}},
+	"lpad": {minArgs: 1, maxArgs: 1, check: checkWidth, apply: func(v string, args []string, _ Vars) string {
+		n, _ := strconv.Atoi(args[0])
+		return strings.Repeat(" ", max(0, n-runewidth.StringWidth(v))) + v
+	}},
+	"center": {minArgs: 1, maxArgs: 1, check: checkWidth, apply: func(v string, args []string, _ Vars) string {
+		n, _ := strconv.Atoi(args[0])
+		space := max(0, n-runewidth.StringWidth(v))
+		return strings.Repeat(" ", space/2) + v + strings.Repeat(" ", space-space/2)
+	}},
+	"default": {minArgs: 1, maxArgs: 1, apply: func(v string, args []string, _ Vars) string {
+		if isSet(v) {
+			return v
+		}
+		return args[0]
+	}},
+	"or": {minArgs: 1, maxArgs: 1, check: checkName, apply: func(v string, args []string, vars Vars) string {
+		if isSet(v) {
+			return v
+		}
+		return vars[args[0]]
+	}},
+}
+
+// parseFilter parses the filter with its arguments, e.g. "trunc:20:..."
+func parseFilter(s string) (filter, error) {
+	name, rest, hasArgs := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+
+	kind, ok := filters[name]
+	if !ok {
+		return filter{}, fmt.Errorf("'%s' is not a known filter (%s)", name, filterNames())
+	}
+
+	var args []string
+	if hasArgs && kind.maxArgs > 0 {
+		args = strings.SplitN(rest, ":", kind.maxArgs)
+	} else if hasArgs {
+		return filter{}, fmt.Errorf("'%s' takes no arguments", name)
+	}
+	if len(args) < kind.minArgs {
+		return filter{}, fmt.Errorf("'%s' needs an argument, e.g. '%s:10'", name, name)
+	}
+	if kind.check != nil {
+		if err := kind.check(args); err != nil {
+			return filter{}, fmt.Errorf("'%s': %w", name, err)
+		}
+	}
+
+	return filter{name: name, args: args, apply: kind.apply}, nil
+}
+
+func filterNames() string {
+	return "upper, lower, title, trunc, pad, lpad, center, default, or"
+}
+
+func checkWidth(args []string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(args[0])); err != nil || n < 1 {
+		return fmt.Errorf("'%s' is not a positive number of columns", args[0])
+	}
+	args[0] = strings.TrimSpace(args[0])
+	return nil
+}
+
+func checkName(args []string) error {
+	args[0] = strings.TrimSpace(args[0])
+	if !isName(args[0]) {
+		return fmt.Errorf("'%s' is not a valid variable", args[0])
+	}
+	return nil
+}
+
+// truncate cuts the value to N columns, ending it with the ellipsis if it's cut
+func truncate(v string, args []string, _ Vars) string {
+	n, _ := strconv.Atoi(args[0])
+	ellipsis := "…"
+	if len(args) > 1 {
+		ellipsis = args[1]
+	}
+
+	if runewidth.StringWidth(v) <= n {
+		return v
+	}
+	keep := max(0, n-runewidth.StringWidth(ellipsis))
+	return strings.TrimRightFunc(runewidth.Truncate(v, keep, ""), unicode.IsSpace) + ellipsis
+}
+
+// toTitle capitalizes the first letter of every word
+func toTitle(v string) string {
+	runes := []rune(v)
+	start := true
+	for i, r := range runes {
+		if start {
+			runes[i] = unicode.ToUpper(r)
+		}
+		start = unicode.IsSpace(r)
+	}
+	return string(runes)
+}
@@ -0,0 +1,184 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+)
+
+type node any
+
+// literal is the text between the actions
+type literal string
+
+// variable is a variable's value passed through the filters
+type variable struct {
+	name    string
+	filters []filter
+}
+
+// condition shows one of the parts depending on whether the variable is set
+type condition struct {
+	name      string
+	negate    bool
+	then      []node
+	otherwise []node
+}
+
+// SyntaxError is an error in the format with the position (in characters) it's found at
+type SyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("at character %d: %s", e.Position, e.Message)
+}
+
+// Parse parses the format into a template
+func Parse(format string) (*Template, error) {
+	p := parser{format: []rune(format)}
+	nodes, end, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, &SyntaxError{p.pos, "{" + end + "} without {if}"}
+	}
+	return &Template{nodes: nodes}, nil
+}
+
+type parser struct {
+	format []rune
+	pos    int
+}
+
+// parse parses the nodes until the end of the format or until {else} or {end} is found,
+// which is returned with the nodes
+func (p *parser) parse(depth int) (nodes []node, end string, err error) {
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, literal(text.String()))
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.format) {
+		r := p.format[p.pos]
+		switch {
+		case (r == '{' || r == '}') && p.pos+1 < len(p.format) && p.format[p.pos+1] == r:
+			text.WriteRune(r)
+			p.pos += 2
+		case r == '}':
+			return nil, "", &SyntaxError{p.pos + 1, "'}' without '{' (use '}}' for the brace itself)"}
+		case r == '{':
+			start := p.pos
+			closing := p.find('}')
+			if closing == -1 {
+				return nil, "", &SyntaxError{start + 1, "'{' is never closed (use '{{' for the brace itself)"}
+			}
+			action := strings.TrimSpace(string(p.format[p.pos+1 : closing]))
+			p.pos = closing + 1
+			flush()
+
+			switch {
+			case action == "else" || action == "end":
+				if depth == 0 {
+					return nil, "", &SyntaxError{start + 1, "{" + action + "} without {if}"}
+				}
+				return nodes, action, nil
+			case strings.HasPrefix(action, "if ") || action == "if":
+				c, err := p.parseCondition(start, strings.TrimSpace(strings.TrimPrefix(action, "if")), depth)
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, c)
+			default:
+				v, err := parseVariable(start, action)
+				if err != nil {
+					return nil, "", err
+				}
+				nodes = append(nodes, v)
+			}
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	flush()
+
+	if depth > 0 {
+		return nil, "", &SyntaxError{len(p.format), "{if} is never closed with {end}"}
+	}
+	return nodes, "", nil
+}
+
+// parseCondition parses the parts of the {if} that starts at the position
+func (p *parser) parseCondition(start int, name string, depth int) (condition, error) {
+	c := condition{}
+	c.negate = strings.HasPrefix(name, "!")
+	c.name = strings.TrimSpace(strings.TrimPrefix(name, "!"))
+	if !isName(c.name) {
+		return c, &SyntaxError{start + 1, fmt.Sprintf("'%s' is not a variable name to check in {if}", c.name)}
+	}
+
+	then, end, err := p.parse(depth + 1)
+	if err != nil {
+		return c, err
+	}
+	c.then = then
+	if end == "else" {
+		otherwise, end, err := p.parse(depth + 1)
+		if err != nil {
+			return c, err
+		}
+		if end != "end" {
+			return c, &SyntaxError{p.pos, "{else} is followed by another {else} instead of {end}"}
+		}
+		c.otherwise = otherwise
+	}
+
+	return c, nil
+}
+
+// parseVariable parses the variable with its filters, e.g. "title|upper|trunc:20"
+func parseVariable(start int, action string) (variable, error) {
+	parts := strings.Split(action, "|")
+	v := variable{name: strings.TrimSpace(parts[0])}
+	if !isName(v.name) {
+		return v, &SyntaxError{start + 1, fmt.Sprintf("'{%s}' is not a valid variable", action)}
+	}
+
+	for _, part := range parts[1:] {
+		f, err := parseFilter(part)
+		if err != nil {
+			return v, &SyntaxError{start + 1, err.Error()}
+		}
+		v.filters = append(v.filters, f)
+	}
+
+	return v, nil
+}
+
+// find returns the position of the rune from the current one, or -1
+func (p *parser) find(r rune) int {
+	for i := p.pos; i < len(p.format); i++ {
+		if p.format[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// isName reports whether the string is a valid variable name: lowercase letters, digits and dashes
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package template
+
+import (
+	"strings"
+
+	"lrcsnc/internal/pkg/structs"
+)
+
+// SongNames are the names of the song's variables, which every format has
+var SongNames = []string{"artist", "artists", "title", "album"}
+
+// PipedNames are the names of the variables that every format of the piped output has.
+// They are kept here, so that the config can check the formats without depending on the output
+var PipedNames = append([]string{
+	"icon", "lyric", "multiplier", "value", "symbol", "text",
+	"prev", "next", "next2", "context",
+	"player", "position", "duration", "playback-status", "lyrics-status",
+	"song-progress", "line-progress", "gap-remaining",
+}, SongNames...)
+
+// SongVars returns the song's variables: the first artist, all the artists, the title and the album
+func SongVars(song structs.Song) Vars {
+	var artist string
+	if len(song.Artists) > 0 {
+		artist = song.Artists[0]
+	}
+
+	return Vars{
+		"artist":  artist,
+		"artists": strings.Join(song.Artists, ", "),
+		"title":   song.Title,
+		"album":   song.Album,
+	}
+}
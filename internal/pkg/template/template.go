@@ -0,0 +1,163 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Vars are the values of the variables used in the templates
+type Vars map[string]string
+
+// Finish is applied to every variable's value after its filters,
+// e.g. to escape it for markup. A nil Finish leaves the values as they are
+type Finish func(name, value string) string
+
+// Template is a parsed format.
+//
+// A format is text with actions in braces:
+//   - {name} is the value of the variable;
+//   - {name|filter|filter:arg} passes the value through the filters (see filters.go);
+//   - {if name}...{else}...{end} shows the first part if the variable is not empty and the second one otherwise.
+//     {if !name} is the opposite, and {else} is optional;
+//   - {{ and }} are the braces themselves.
+type Template struct {
+	nodes []node
+}
+
+// cache keeps the parsed formats, since the same ones are rendered over and over
+var cache sync.Map
+
+// Render renders the format with the variables.
+// A format that can't be parsed (which is caught at config validation) is returned as it is.
+func Render(format string, vars Vars, finish Finish) string {
+	var t *Template
+	if cached, ok := cache.Load(format); ok {
+		t = cached.(*Template)
+	} else {
+		parsed, err := Parse(format)
+		if err != nil {
+			return format
+		}
+		cache.Store(format, parsed)
+		t = parsed
+	}
+
+	return t.Execute(vars, finish)
+}
+
+// Execute renders the template with the variables.
+// The unknown variables are empty.
+func (t *Template) Execute(vars Vars, finish Finish) string {
+	var b strings.Builder
+	execute(t.nodes, vars, finish, &b)
+	return b.String()
+}
+
+func execute(nodes []node, vars Vars, finish Finish, b *strings.Builder) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case literal:
+			b.WriteString(string(n))
+		case variable:
+			value := vars[n.name]
+			for _, f := range n.filters {
+				value = f.apply(value, f.args, vars)
+			}
+			if finish != nil {
+				value = finish(n.name, value)
+			}
+			b.WriteString(value)
+		case condition:
+			if isSet(vars[n.name]) != n.negate {
+				execute(n.then, vars, finish, b)
+			} else {
+				execute(n.otherwise, vars, finish, b)
+			}
+		}
+	}
+}
+
+// isSet reports whether the value counts as set in the conditions and fallbacks
+func isSet(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+// Validate parses the format and checks that it uses only the variables with the names.
+// The error tells where the problem is and, for a misspelled variable, what it may have meant.
+func Validate(format string, names []string) error {
+	t, err := Parse(format)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range t.names() {
+		if !slices.Contains(names, name) {
+			message := fmt.Sprintf("'%s' is not a known variable", name)
+			if suggestion := closest(name, names); suggestion != "" {
+				message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+			}
+			return errors.New(message)
+		}
+	}
+
+	return nil
+}
+
+// names returns the names of the variables the template uses
+func (t *Template) names() (names []string) {
+	var walk func(nodes []node)
+	walk = func(nodes []node) {
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case variable:
+				names = append(names, n.name)
+				for _, f := range n.filters {
+					if f.name == "or" {
+						names = append(names, f.args[0])
+					}
+				}
+			case condition:
+				names = append(names, n.name)
+				walk(n.then)
+				walk(n.otherwise)
+			}
+		}
+	}
+	walk(t.nodes)
+	return
+}
+
+// closest returns the name that is the closest to the misspelled one, if it's close enough
+func closest(misspelled string, names []string) (best string) {
+	bestDistance := 3
+	for _, name := range names {
+		if d := distance(misspelled, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return
+}
+
+// distance is the Levenshtein distance between the strings
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
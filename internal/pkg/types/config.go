@@ -26,22 +26,122 @@ const (
 
 // OutputType is a type of output to use.
 //
-// Possible values: "piped".
+// Possible values: "piped", "dbus", "tui", "http", "socket", "notify", "mpv", "webhook".
 type OutputType string
 
 const (
-	OutputPiped OutputType = "piped"
+	OutputPiped   OutputType = "piped"
+	OutputDBus    OutputType = "dbus"
+	OutputTUI     OutputType = "tui"
+	OutputHTTP    OutputType = "http"
+	OutputSocket  OutputType = "socket"
+	OutputNotify  OutputType = "notify"
+	OutputMPV     OutputType = "mpv"
+	OutputWebhook OutputType = "webhook"
 )
 
 // JSONOutputType is a variant of JSON output to use in piped output
 //
-// Possible values: "none", "generic", "waybar"
+// Possible values: "none", "generic", "waybar", "i3bar"
 type JSONOutputType string
 
 const (
 	JSONOutputNone    JSONOutputType = "none"
 	JSONOutputGeneric JSONOutputType = "generic"
 	JSONOutputWaybar  JSONOutputType = "waybar"
+	JSONOutputI3bar   JSONOutputType = "i3bar"
+)
+
+// ProgressType is the kind of progress shown as the percentage in piped output
+//
+// Possible values: "none", "song", "line"
+type ProgressType string
+
+const (
+	ProgressNone ProgressType = "none"
+	ProgressSong ProgressType = "song"
+	ProgressLine ProgressType = "line"
+)
+
+// IntroBehaviour is what the output shows before the first lyric line
+//
+// Possible values: "instrumental", "title", "countdown", "empty"
+type IntroBehaviour string
+
+const (
+	IntroInstrumental IntroBehaviour = "instrumental"
+	IntroTitle        IntroBehaviour = "title"
+	IntroCountdown    IntroBehaviour = "countdown"
+	IntroEmpty        IntroBehaviour = "empty"
+)
+
+// OutroBehaviour is what the output shows after the last lyric line
+//
+// Possible values: "hold", "clear", "end"
+type OutroBehaviour string
+
+const (
+	OutroHold  OutroBehaviour = "hold"
+	OutroClear OutroBehaviour = "clear"
+	OutroEnd   OutroBehaviour = "end"
+)
+
+// NotifyMode is when the notification output sends a notification
+//
+// Possible values: "line", "song"
+type NotifyMode string
+
+const (
+	NotifyLine NotifyMode = "line"
+	NotifySong NotifyMode = "song"
+)
+
+// NotifyUrgency is the urgency level of the notifications
+//
+// Possible values: "low", "normal", "critical"
+type NotifyUrgency string
+
+const (
+	NotifyUrgencyLow      NotifyUrgency = "low"
+	NotifyUrgencyNormal   NotifyUrgency = "normal"
+	NotifyUrgencyCritical NotifyUrgency = "critical"
+)
+
+// Byte returns the urgency as it's passed to the notification server
+func (u NotifyUrgency) Byte() byte {
+	switch u {
+	case NotifyUrgencyLow:
+		return 0
+	case NotifyUrgencyCritical:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// OverflowMode is what the piped output does with the lyrics that don't fit into the max width
+//
+// Possible values: "truncate", "wrap", "marquee"
+type OverflowMode string
+
+const (
+	// OverflowTruncate cuts the lyric, ending it with the ellipsis
+	OverflowTruncate OverflowMode = "truncate"
+	// OverflowWrap splits the lyric into parts that are shown one after another during the line
+	OverflowWrap OverflowMode = "wrap"
+	// OverflowMarquee scrolls the lyric during the line
+	OverflowMarquee OverflowMode = "marquee"
+)
+
+// WebhookEventType is a kind of event the webhook output sends
+//
+// Possible values: "song", "line", "lyrics-state"
+type WebhookEventType string
+
+const (
+	WebhookSong        WebhookEventType = "song"
+	WebhookLine        WebhookEventType = "line"
+	WebhookLyricsState WebhookEventType = "lyrics-state"
 )
 
 // LogLevelType represents the log level to use in logger.
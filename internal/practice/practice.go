@@ -0,0 +1,60 @@
+package practice
+
+import "sync"
+
+// Loop is a range of lyric lines that is played over and over again.
+type Loop struct {
+	// Start and End are the indexes of the first and the last lyric lines of the range
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Rate is the playback rate to use while looping; 0 means it's left untouched
+	Rate float64 `json:"rate"`
+	// Count is how many times the playback was sent back to the start of the range
+	Count int `json:"count"`
+}
+
+var state = struct {
+	M      sync.Mutex
+	L      Loop
+	Active bool
+}{}
+
+// Start activates the practice loop.
+func Start(l Loop) {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	l.Count = 0
+	state.L = l
+	state.Active = true
+}
+
+// Stop deactivates the practice loop and returns the one that was active (if any).
+func Stop() (Loop, bool) {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	l, wasActive := state.L, state.Active
+	state.L = Loop{}
+	state.Active = false
+
+	return l, wasActive
+}
+
+// Get returns the active practice loop (if any).
+func Get() (Loop, bool) {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	return state.L, state.Active
+}
+
+// CountLoop increments the loop count of the active practice loop.
+func CountLoop() {
+	state.M.Lock()
+	defer state.M.Unlock()
+
+	if state.Active {
+		state.L.Count++
+	}
+}
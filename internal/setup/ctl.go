@@ -0,0 +1,118 @@
+package setup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/drift"
+)
+
+// ctlCommand is the `lrcsnc ctl` subcommand that talks to a running instance through the control socket
+type ctlCommand struct {
+	Socket string `short:"s" long:"socket" description:"Sets the control socket path (must match the one in the running instance's config)" default:"$XDG_RUNTIME_DIR/lrcsnc.sock" env:"LRCSNC_SOCKET"`
+	JSON   bool   `short:"j" long:"json" description:"Prints the returned data as JSON"`
+}
+
+// Execute sends the command to the running instance, prints the answer and exits.
+// It doesn't touch the logger on purpose: the log file belongs to the running instance.
+func (c *ctlCommand) Execute(args []string) error {
+	if _, _, err := control.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n\n%s\n", strings.Join(args, " "), err.Error(), control.Usage)
+		os.Exit(2)
+	}
+
+	resp, err := control.Request(c.Socket, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to reach the running instance: "+err.Error())
+		os.Exit(1)
+	}
+	if !resp.OK {
+		fmt.Fprintln(os.Stderr, "Error: "+resp.Error)
+		os.Exit(1)
+	}
+
+	if len(resp.Data) != 0 {
+		switch {
+		case c.JSON:
+			fmt.Println(string(resp.Data))
+		case args[0] == "status":
+			var s control.Status
+			if err := json.Unmarshal(resp.Data, &s); err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to read the status: "+err.Error())
+				os.Exit(1)
+			}
+			printStatus(s)
+		case args[0] == "drift":
+			var d map[string]drift.Stats
+			if err := json.Unmarshal(resp.Data, &d); err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to read the drift statistics: "+err.Error())
+				os.Exit(1)
+			}
+			printDrift(d)
+		default:
+			fmt.Println(string(resp.Data))
+		}
+	}
+
+	os.Exit(0)
+	return nil
+}
+
+func printStatus(s control.Status) {
+	formatTime := func(t float64) string {
+		return fmt.Sprintf("%02d:%02d", int(t)/60, int(t)%60)
+	}
+
+	if s.Player == "" {
+		fmt.Println("Player:   none")
+	} else {
+		fmt.Printf("Player:   %s (%s)\n", s.Player, strings.ToLower(s.PlaybackStatus))
+		fmt.Printf("Song:     %s - %s (%s)\n", strings.Join(s.Artists, ", "), s.Title, s.Album)
+		fmt.Printf("Position: %s / %s\n", formatTime(s.Position), formatTime(s.Duration))
+	}
+	fmt.Printf("Lyrics:   %s\n", s.LyricsState)
+	if s.LineIndex >= 0 {
+		fmt.Printf("Line:     #%d %s\n", s.LineIndex, s.Line)
+	}
+	fmt.Printf("Offset:   %+.2fs", s.Offset)
+	if s.OffsetProfile != "" {
+		fmt.Printf(" (%s)", s.OffsetProfile)
+	}
+	if s.SongOffset != 0 {
+		fmt.Printf(", %+.2fs for the song", s.SongOffset)
+	}
+	fmt.Println()
+	if s.Loop != nil {
+		fmt.Printf("Loop:     lines %d-%d, looped %d times", s.Loop.Start, s.Loop.End, s.Loop.Count)
+		if s.Loop.Rate != 0 {
+			fmt.Printf(" at %.2fx", s.Loop.Rate)
+		}
+		fmt.Println()
+	}
+	if s.Drift != nil {
+		fmt.Printf("Drift:    %+.3fs last, %.3fs max, %d/%d checks corrected\n", s.Drift.Last, s.Drift.Max, s.Drift.Corrections, s.Drift.Checks)
+	}
+}
+
+func printDrift(d map[string]drift.Stats) {
+	if len(d) == 0 {
+		fmt.Println("No players were checked yet")
+		return
+	}
+
+	players := make([]string, 0, len(d))
+	for p := range d {
+		players = append(players, p)
+	}
+	slices.Sort(players)
+
+	fmt.Printf("%-20s %8s %8s %8s  %s\n", "PLAYER", "LAST", "MEAN", "MAX", "CORRECTED")
+	for _, p := range players {
+		s := d[p]
+		fmt.Printf("%-20s %+7.3fs %7.3fs %7.3fs  %d/%d\n", p, s.Last, s.Mean, s.Max, s.Corrections, s.Checks)
+	}
+}
@@ -18,4 +18,10 @@ func CheckDependencies() {
 		log.Info("setup/dependencies", "kakasi not found, disabling Japanese romanization")
 		global.Config.C.Lyrics.Romanization.Japanese = false
 	}
+
+	// pactl - for detecting the audio sink for offset profiles
+	if _, err := exec.LookPath("pactl"); err != nil && global.Config.C.Lyrics.OffsetProfiles.DetectSink {
+		log.Info("setup/dependencies", "pactl not found, disabling audio sink detection")
+		global.Config.C.Lyrics.OffsetProfiles.DetectSink = false
+	}
 }
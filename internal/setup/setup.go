@@ -7,8 +7,10 @@ import (
 	"os"
 
 	"lrcsnc/internal/config"
+	"lrcsnc/internal/control"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
 	"lrcsnc/internal/pkg/types"
 
 	"github.com/jessevdk/go-flags"
@@ -27,12 +29,20 @@ var opts struct {
 	IsPiped            bool   `short:"p" long:"piped" description:"Set the output to 'piped', fully ignoring the config." env:"LRCSNC_PIPED"`
 	OutputFilePath     string `short:"o" long:"output" description:"Sets an output file to use instead of standard output when using piped output" env:"LRCSNC_OUTPUT"`
 	DisplayVersion     bool   `short:"v" long:"version" description:"Display the version"`
+
+	Ctl ctlCommand `command:"ctl" description:"Sends a command to a running instance through the control socket"`
 }
 
 // Setup parses the command line flags (or their environment variable equivalents)
 // and sets up the logger, config and some other settings.
 func Setup() {
-	_, err := flags.Parse(&opts)
+	// Everything after the first non-option argument is passed as is,
+	// so that `lrcsnc ctl offset -0.2` is not treated as a flag
+	parser := flags.NewParser(&opts, flags.Default|flags.PassAfterNonOption)
+	parser.SubcommandsOptional = true
+	parser.Find("ctl").LongDescription = control.Usage
+
+	_, err := parser.Parse()
 	if flags.WroteHelp(err) {
 		os.Exit(0)
 	}
@@ -66,6 +76,12 @@ func Setup() {
 		log.Init()
 	}
 
+	// The click commands in the config are checked the same way as the ones from `lrcsnc ctl`
+	config.CheckCommand = func(args []string) error {
+		_, _, err := control.Parse(args)
+		return err
+	}
+
 	// Try to read config from the provided path
 	if opts.ConfigPath != "" {
 		log.Info("setup", fmt.Sprintf("Trying to read config from the provided path (%v)...", opts.ConfigPath))
@@ -99,22 +115,39 @@ func Setup() {
 		if _, err := os.ReadDir(os.ExpandEnv(opts.CacheDirectory)); err != nil && !os.IsNotExist(err) {
 			log.Error("setup", fmt.Sprintf("The provided cache directory (%v) is invalid and will be ignored.", opts.CacheDirectory))
 		} else {
-			global.Config.C.Cache.Dir = opts.CacheDirectory
+			config.Override(func(c *structs.Config) {
+				c.Cache.Dir = opts.CacheDirectory
+			})
 		}
 	}
 
 	// Explicitly set the output type to "piped" for this app instance if the flag is set
+	// (the configured output instances are dropped too).
+	// The flags are overrides, so that reloading the config doesn't undo them
 	if opts.IsPiped {
-		global.Config.C.Output.Type = "piped"
+		config.Override(func(c *structs.Config) {
+			c.Output.Type = "piped"
+			c.Output.Instances = nil
+		})
+	}
+
+	// The output file path can't tell which of the output instances to use
+	if opts.OutputFilePath != "" && len(global.Config.C.Output.Instances) > 0 {
+		log.Warn("setup", "The output file path is ignored, since there are output instances configured. Set the destinations in the config instead.")
 	}
 
 	// If the output type is "piped", explicitly set the output file path for this app instance if the flag is set
-	if opts.OutputFilePath != "" && global.Config.C.Output.Type == "piped" {
+	if opts.OutputFilePath != "" && global.Config.C.Output.Type == "piped" && len(global.Config.C.Output.Instances) == 0 {
 		// We'll try to write to or create the file on the specified path first to ensure it is valid
 		if _, err := os.OpenFile(opts.OutputFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644); err != nil {
 			log.Error("setup", fmt.Sprintf("The provided output file path (%v) is invalid and will be ignored. Error: %v", opts.OutputFilePath, err))
 		} else {
-			global.Config.C.Output.Piped.Destination = opts.OutputFilePath
+			config.Override(func(c *structs.Config) {
+				// A reloaded config may have output instances, which the path can't tell apart
+				if c.Output.Type == "piped" && len(c.Output.Instances) == 0 {
+					c.Output.Piped.Destination = opts.OutputFilePath
+				}
+			})
 			// The output is not initialized yet, so no events are sent to the output controller
 		}
 	}
@@ -0,0 +1,241 @@
+package sync
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// After this many consecutive readings in whole seconds
+// the player is considered to be reporting the position coarsely
+const coarseReadingsThreshold = 2
+
+// The range of possible positions of a coarse player is narrowed down
+// with new readings until it is this narrow (in seconds)
+const coarsePrecision = 0.05
+
+// PlaybackClock is a model of the player's playback that extrapolates the position
+// from the last known one (the anchor), the time it was known at, the playback rate
+// and whether the playback is going on at all. It is the single source of truth
+// for the position, so the player doesn't have to be asked for it all the time.
+//
+// For most players the anchor is a single point. Players that report the position
+// in whole seconds (like cmus) only tell that the position is somewhere within a second,
+// so the anchor is a range that is narrowed down with each new reading.
+type PlaybackClock struct {
+	m          sync.Mutex
+	anchorLow  float64
+	anchorHigh float64
+	anchorTime time.Time
+	rate       float64
+	playing    bool
+	// integralReadings counts the consecutive readings that were whole seconds
+	integralReadings int
+}
+
+var clock = NewPlaybackClock()
+
+// NewPlaybackClock creates a PlaybackClock that is stopped at the start with the normal rate.
+func NewPlaybackClock() *PlaybackClock {
+	return &PlaybackClock{anchorTime: time.Now(), rate: 1}
+}
+
+// Restart forgets everything known about the previous player
+// and starts anew with the provided data
+func (c *PlaybackClock) Restart(pos float64, rate float64, playing bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.integralReadings = 0
+	c.anchorLow, c.anchorHigh = pos, pos
+	c.anchorTime = time.Now()
+	c.rate = rate
+	c.playing = playing
+}
+
+// Position returns the current extrapolated position in seconds
+func (c *PlaybackClock) Position() float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.positionAt(time.Now())
+}
+
+// Anchor sets the exact position of the player at the current time
+// (e.g. when the position was set by us)
+func (c *PlaybackClock) Anchor(pos float64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.anchorLow, c.anchorHigh = pos, pos
+	c.anchorTime = time.Now()
+}
+
+// Reset applies a reading of the player's position that is known
+// to break the continuity of the playback (e.g. the one from a Seeked signal).
+func (c *PlaybackClock) Reset(pos float64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.countReading(pos)
+	c.anchorLow, c.anchorHigh = c.readingRange(pos)
+	c.anchorTime = time.Now()
+}
+
+// Observe applies a reading of the player's position taken at the time t.
+// It returns the delay after which another reading would narrow the anchor range down
+// (only for coarse players), or false if the position is known precisely enough.
+func (c *PlaybackClock) Observe(pos float64, t time.Time) (time.Duration, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	wasCoarse := c.isCoarse()
+	c.countReading(pos)
+	c.reanchor(t)
+
+	low, high := c.readingRange(pos)
+	if wasCoarse != c.isCoarse() || low > c.anchorHigh || high < c.anchorLow {
+		// Either the way the readings are interpreted has changed, or the reading
+		// doesn't fit the known range at all (the player jumped without telling us
+		// or the extrapolation drifted away). In both cases the reading is taken as is.
+		c.anchorLow, c.anchorHigh = low, high
+	} else {
+		c.anchorLow, c.anchorHigh = max(c.anchorLow, low), min(c.anchorHigh, high)
+	}
+
+	return c.nextRefinement()
+}
+
+// Drift returns how far a reading of the player's position taken at the time t
+// is from the extrapolated position. For coarse players it is zero
+// as long as the reading's range contains the extrapolated position.
+func (c *PlaybackClock) Drift(pos float64, t time.Time) float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e := c.elapsedAt(t)
+	low, high := c.readingRange(pos)
+	switch {
+	case high < c.anchorLow+e:
+		return high - (c.anchorLow + e)
+	case low > c.anchorHigh+e:
+		return low - (c.anchorHigh + e)
+	default:
+		return 0
+	}
+}
+
+// SetPlaying changes whether the playback is going on.
+// The position reached so far becomes the new anchor.
+func (c *PlaybackClock) SetPlaying(playing bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.reanchor(time.Now())
+	c.playing = playing
+}
+
+// SetRate changes the playback rate.
+// The position reached with the old rate becomes the new anchor.
+func (c *PlaybackClock) SetRate(rate float64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.reanchor(time.Now())
+	c.rate = rate
+}
+
+// Rate returns the current playback rate
+func (c *PlaybackClock) Rate() float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.rate
+}
+
+// Playing returns whether the playback is going on
+func (c *PlaybackClock) Playing() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.playing
+}
+
+// PositionAt returns the extrapolated position in seconds at the time t
+func (c *PlaybackClock) PositionAt(t time.Time) float64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.positionAt(t)
+}
+
+// Everything below does NOT lock the mutex.
+
+// positionAt returns the extrapolated position at the time t:
+// the middle of the anchor range moved by the playback since the anchor time
+func (c *PlaybackClock) positionAt(t time.Time) float64 {
+	return (c.anchorLow+c.anchorHigh)/2 + c.elapsedAt(t)
+}
+
+// elapsedAt returns how far the playback went from the anchor time to the time t
+func (c *PlaybackClock) elapsedAt(t time.Time) float64 {
+	if !c.playing {
+		return 0
+	}
+	return t.Sub(c.anchorTime).Seconds() * c.rate
+}
+
+// reanchor moves the anchor to the time t, keeping the width of the anchor range
+func (c *PlaybackClock) reanchor(t time.Time) {
+	e := c.elapsedAt(t)
+	c.anchorLow += e
+	c.anchorHigh += e
+	c.anchorTime = t
+}
+
+func (c *PlaybackClock) isCoarse() bool {
+	return c.integralReadings >= coarseReadingsThreshold
+}
+
+// countReading keeps track of whether the player reports the position in whole seconds.
+// Zero is ambiguous, so it's not counted either way.
+func (c *PlaybackClock) countReading(pos float64) {
+	if pos == 0 {
+		return
+	}
+	if pos == math.Trunc(pos) {
+		c.integralReadings++
+	} else {
+		c.integralReadings = 0
+	}
+}
+
+// readingRange returns the range of possible positions for the reading
+func (c *PlaybackClock) readingRange(pos float64) (float64, float64) {
+	if c.isCoarse() {
+		return pos, pos + 1
+	}
+	return pos, pos
+}
+
+// nextRefinement returns the delay after which the middle of the anchor range crosses
+// a whole second. A reading at that moment tells which half of the range the true position
+// is in, so every such reading halves the range.
+//
+// If the last reading was a whole second but the player is not known to be coarse yet,
+// another reading half a second later settles it.
+func (c *PlaybackClock) nextRefinement() (time.Duration, bool) {
+	if c.integralReadings == 0 || !c.playing || c.rate <= 0 {
+		return 0, false
+	}
+	if !c.isCoarse() {
+		return time.Duration(0.5 / c.rate * float64(time.Second)), true
+	}
+	if c.anchorHigh-c.anchorLow <= coarsePrecision {
+		return 0, false
+	}
+
+	mid := (c.anchorLow + c.anchorHigh) / 2
+	boundary := math.Floor(mid) + 1
+	return time.Duration((boundary - mid) / c.rate * float64(time.Second)), true
+}
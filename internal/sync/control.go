@@ -0,0 +1,247 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"lrcsnc/internal/cache"
+	"lrcsnc/internal/config"
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/drift"
+	"lrcsnc/internal/mpris"
+	"lrcsnc/internal/offset"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/types"
+	"lrcsnc/internal/practice"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// controlCommandReceiver handles the commands sent from control surfaces
+// (D-Bus service, control socket, USR1 signal, etc.) through control.CommandChannel.
+func controlCommandReceiver() {
+	for cmd := range control.CommandChannel {
+		log.Debug("sync/controlCommandReceiver", fmt.Sprintf("Received command: %v", cmd))
+
+		var data any
+		var err error
+		switch cmd.Type {
+		case control.CommandReload:
+			err = config.Update()
+			if err == nil {
+				global.Config.M.Lock()
+				stateFile := global.Config.C.Lyrics.OffsetProfiles.StateFile
+				global.Config.M.Unlock()
+				_ = offset.SetStateFile(stateFile)
+
+				updateSinkWatcher()
+				currentOutput().OnConfigUpdate()
+				// The timestamp offset might have changed
+				resyncLyrics()
+				// The outputs started by the reload need the current line too
+				outputUpdate()
+			}
+		case control.CommandRefetch:
+			global.Player.M.Lock()
+			isStopped := global.Player.P.PlaybackStatus == mprislib.PlaybackStopped
+			if !isStopped {
+				global.Player.P.Song.LyricsData.LyricsState = types.LyricsStateLoading
+			}
+			global.Player.M.Unlock()
+
+			if isStopped {
+				err = errs.ErrNothingPlaying
+				break
+			}
+
+			outputUpdate()
+			refetchRequested <- true
+		case control.CommandSetOffset, control.CommandAdjustOffset:
+			v, ok := cmd.Data.(float64)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			if cmd.Type == control.CommandSetOffset {
+				offset.Set(v)
+			} else {
+				offset.Adjust(v)
+			}
+			offsetChanged()
+		case control.CommandResetOffset:
+			offset.Reset()
+			offsetChanged()
+		case control.CommandSetSongOffset, control.CommandAdjustSongOffset:
+			v, ok := cmd.Data.(float64)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			err = changeSongOffset(v, cmd.Type == control.CommandAdjustSongOffset)
+		case control.CommandDropCache:
+			global.Player.M.Lock()
+			song := global.Player.P.Song
+			isStopped := global.Player.P.PlaybackStatus == mprislib.PlaybackStopped
+			global.Player.M.Unlock()
+
+			if isStopped {
+				err = errs.ErrNothingPlaying
+				break
+			}
+
+			err = cache.Remove(&song)
+		case control.CommandPlayer:
+			switch cmd.Data {
+			case control.PlayerNext:
+				err = mpris.Next()
+			case control.PlayerPrevious:
+				err = mpris.Previous()
+			case control.PlayerPlayPause:
+				err = mpris.PlayPause()
+			default:
+				err = errs.ErrInvalidCommandData
+			}
+		case control.CommandSeekLine, control.CommandSeekRelativeLine:
+			v, ok := cmd.Data.(int)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			if cmd.Type == control.CommandSeekLine {
+				err = seekToLine(v)
+			} else {
+				err = seekToRelativeLine(v)
+			}
+		case control.CommandSeek, control.CommandSeekRelative:
+			v, ok := cmd.Data.(float64)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			err = seekBy(v, cmd.Type == control.CommandSeekRelative)
+		case control.CommandSeekPhrase:
+			v, ok := cmd.Data.(string)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			err = seekToPhrase(v)
+		case control.CommandStartLoop:
+			v, ok := cmd.Data.(practice.Loop)
+			if !ok {
+				err = errs.ErrInvalidCommandData
+				break
+			}
+			err = startPracticeLoop(v)
+		case control.CommandStopLoop:
+			stopPracticeLoop()
+		case control.CommandStatus:
+			data = status()
+		case control.CommandDrift:
+			data = drift.All()
+		case control.CommandQuit:
+			log.Info("sync/controlCommandReceiver", "Quit requested through a control command")
+			// Going through the signal ensures the same shutdown path as Ctrl+C.
+			// The result is sent first, so the requester gets the answer before everything closes.
+			if cmd.Result != nil {
+				cmd.Result <- control.Result{}
+			}
+			syscall.Kill(os.Getpid(), syscall.SIGTERM)
+			continue
+		default:
+			err = errs.ErrUnknownCommand
+		}
+
+		if err != nil {
+			log.Error("sync/controlCommandReceiver", err.Error())
+		}
+		if cmd.Result != nil {
+			cmd.Result <- control.Result{Data: data, Err: err}
+		}
+	}
+}
+
+// offsetChanged notifies everything that depends on the timestamp offset
+func offsetChanged() {
+	v := offset.Get()
+	log.Info("sync/controlCommandReceiver", fmt.Sprintf("The timestamp offset is now %.2fs", v))
+
+	currentOutput().OnConfigUpdate()
+	currentOutput().OnOverwrite(fmt.Sprintf("Offset: %+.2fs", v))
+	resyncLyrics()
+}
+
+// changeSongOffset sets (or shifts if relative) the current song's own timestamp offset
+// and stores it in the song's cache entry, so it's applied every time the song plays
+func changeSongOffset(v float64, relative bool) error {
+	global.Player.M.Lock()
+	if global.Player.P.Song.LyricsData.LyricsState != types.LyricsStateSynced {
+		global.Player.M.Unlock()
+		return errs.ErrNoSyncedLyrics
+	}
+	if relative {
+		v += global.Player.P.Song.LyricsData.Offset
+	}
+	global.Player.P.Song.LyricsData.Offset = v
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+
+	log.Info("sync/controlCommandReceiver", fmt.Sprintf("The song's own timestamp offset is now %.2fs", v))
+	if err := cache.StoreOffset(&song); err != nil {
+		log.Warn("sync/controlCommandReceiver", "The song's offset is applied, but couldn't be stored in the cache: "+err.Error())
+	}
+
+	currentOutput().OnOverwrite(fmt.Sprintf("Song offset: %+.2fs", v))
+	resyncLyrics()
+
+	return nil
+}
+
+func status() control.Status {
+	// Both lock other mutexes, so they're read before the player is locked
+	timestampOffset := offset.Get()
+	lyricIndex := currentLine()
+
+	global.Player.M.Lock()
+	defer global.Player.M.Unlock()
+
+	s := control.Status{
+		Player:         global.Player.P.Name,
+		PlaybackStatus: string(global.Player.P.PlaybackStatus),
+		Title:          global.Player.P.Song.Title,
+		Artists:        global.Player.P.Song.Artists,
+		Album:          global.Player.P.Song.Album,
+		Position:       global.Player.P.Position,
+		Duration:       global.Player.P.Song.Duration,
+		LyricsState:    global.Player.P.Song.LyricsData.LyricsState.String(),
+		LineIndex:      -1,
+		Offset:         timestampOffset,
+		OffsetProfile:  offset.Profile(),
+		SongOffset:     global.Player.P.Song.LyricsData.Offset,
+	}
+
+	// The stored position is only updated on events and lines,
+	// so it's extrapolated while playing
+	if global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying {
+		s.Position = clock.Position()
+	}
+
+	if global.Player.P.Song.LyricsData.LyricsState == types.LyricsStateSynced &&
+		lyricIndex >= 0 && lyricIndex < len(global.Player.P.Song.LyricsData.Lyrics) {
+		s.LineIndex = lyricIndex
+		s.Line = global.Player.P.Song.LyricsData.Lyrics[lyricIndex].Text
+	}
+
+	if l, ok := practice.Get(); ok {
+		s.Loop = &l
+	}
+
+	if d, ok := drift.Get(global.Player.P.Name); ok {
+		s.Drift = &d
+	}
+
+	return s
+}
@@ -0,0 +1,70 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"lrcsnc/internal/drift"
+	"lrcsnc/internal/mpris"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+)
+
+// How often the config is looked at while the drift checks are disabled
+const disabledDriftCheckInterval = 10 * time.Second
+
+// This is a drift checker.
+// Some players (mostly browsers and Electron apps) don't emit Seeked signals
+// when the position is changed by the user, and the extrapolated position
+// may also slowly drift away from the real one over time.
+//
+// So every once in a while the predicted position is compared with the player's one,
+// and if the difference is over the threshold, the position is synchronized again.
+// The results of the checks are recorded per player to tell which ones misbehave.
+func driftChecker() {
+	for {
+		global.Config.M.Lock()
+		dc := global.Config.C.Player.DriftCorrection
+		global.Config.M.Unlock()
+
+		interval := time.Duration(dc.Interval * float64(time.Second))
+		if !dc.Enabled {
+			// The checks may be enabled by a config reload later
+			interval = disabledDriftCheckInterval
+		}
+		time.Sleep(interval)
+
+		if !dc.Enabled || !clock.Playing() {
+			continue
+		}
+
+		global.Player.M.Lock()
+		player := global.Player.P.Name
+		global.Player.M.Unlock()
+
+		if player == "" {
+			continue
+		}
+
+		// The reading is considered to be taken in the middle of the D-Bus round trip
+		requestTime := time.Now()
+		pos, err := mpris.GetPosition()
+		if err != nil {
+			log.Error("sync/driftChecker", "Couldn't get the position: "+err.Error())
+			continue
+		}
+		readingTime := requestTime.Add(time.Since(requestTime) / 2)
+
+		d := clock.Drift(pos, readingTime)
+		corrected := math.Abs(d) > dc.Threshold
+		drift.Record(player, d, corrected)
+
+		if corrected {
+			log.Info("sync/driftChecker", fmt.Sprintf("The position of %s drifted by %+.3fs, synchronizing it again", player, d))
+			AskForPositionSync()
+		} else {
+			log.Debug("sync/driftChecker", fmt.Sprintf("The position of %s drifted by %+.3fs", player, d))
+		}
+	}
+}
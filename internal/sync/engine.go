@@ -0,0 +1,209 @@
+package sync
+
+import (
+	"sort"
+	"time"
+
+	"lrcsnc/internal/pkg/structs"
+)
+
+// 5999.99s is basically the maximum limit of .lrc files' timestamps AFAIK, so 6000s is unreachable
+const unreachableTimestamp = 6000.0
+
+// Clock is the source of the playback position for the Engine.
+type Clock interface {
+	// Position returns the current playback position in seconds
+	Position() float64
+	// Rate returns the current playback rate
+	Rate() float64
+	// Playing returns whether the playback is going on
+	Playing() bool
+}
+
+// EngineState is the state of the Engine's state machine.
+type EngineState uint8
+
+const (
+	// StateNoLyrics means there are no synced lyrics to follow
+	StateNoLyrics EngineState = iota
+	// StateStill means the playback doesn't move (it's paused, stopped or at a rate of 0),
+	// so there is nothing to wait for until something changes
+	StateStill
+	// StateFollowing means the playback moves and the engine waits for the next line
+	// (or the end of the practice loop)
+	StateFollowing
+)
+
+// Step is the outcome of Engine.Tick.
+type Step struct {
+	// Display tells whether the line should be displayed
+	Display bool
+	// Index is the index of the current lyric line, or -1 if there is none
+	// (before the first line or without synced lyrics).
+	// Once the outro starts, it's the number of lines
+	Index int
+	// LoopEnded tells that the playback has passed the end of the practice loop
+	// and should be sent back to its start
+	LoopEnded bool
+	// Wait is the time until the next tick is needed, 0 if it isn't needed at all
+	Wait time.Duration
+}
+
+// Engine decides which lyric line should be displayed at the clock's position
+// and when to look again. It holds no global state and is not safe for concurrent use:
+// it is owned by a single goroutine that feeds it the changes and ticks it.
+type Engine struct {
+	clock Clock
+
+	lyrics  []structs.Lyric
+	synced  bool
+	offset  float64
+	loopEnd float64
+	looping bool
+	// outroAfter is how long the last line lasts before the outro starts
+	outroAfter float64
+	outro      bool
+
+	state EngineState
+	index int
+	// displayed is the index of the last displayed line,
+	// -2 if nothing is displayed yet (or it should be displayed again)
+	displayed int
+}
+
+// NewEngine creates an Engine following the clock. It starts in StateNoLyrics.
+func NewEngine(c Clock) *Engine {
+	return &Engine{
+		clock:     c,
+		state:     StateNoLyrics,
+		index:     -1,
+		displayed: -2,
+	}
+}
+
+// SetLyrics sets the lyrics to follow. The lyrics must be sorted by time.
+// If synced is false, the lyrics are not followed at all.
+func (e *Engine) SetLyrics(lyrics []structs.Lyric, synced bool) {
+	if synced != e.synced || !sameLyrics(lyrics, e.lyrics) {
+		e.index = -1
+		e.displayed = -2
+	}
+	e.lyrics = lyrics
+	e.synced = synced
+}
+
+// SetOffset sets the timestamp offset in seconds that is added to every lyric line.
+func (e *Engine) SetOffset(offset float64) {
+	e.offset = offset
+}
+
+// SetLoopEnd sets the position at which the practice loop ends.
+func (e *Engine) SetLoopEnd(end float64) {
+	e.loopEnd = end
+	e.looping = true
+}
+
+// ClearLoop forgets the practice loop's end.
+func (e *Engine) ClearLoop() {
+	e.looping = false
+}
+
+// SetOutro makes the outro start after the last line has lasted for the given seconds.
+func (e *Engine) SetOutro(after float64) {
+	e.outroAfter = after
+	e.outro = true
+}
+
+// ClearOutro makes the last line last until the end of the song.
+func (e *Engine) ClearOutro() {
+	e.outro = false
+}
+
+// Redisplay makes the next tick display the current line even if it was displayed already.
+func (e *Engine) Redisplay() {
+	e.displayed = -2
+}
+
+// State returns the current state of the engine.
+func (e *Engine) State() EngineState {
+	return e.state
+}
+
+// Index returns the index of the current lyric line as of the last tick,
+// or -1 if there is none.
+func (e *Engine) Index() int {
+	return e.index
+}
+
+// Tick looks at the clock and decides what should be displayed and when to tick again.
+func (e *Engine) Tick() Step {
+	if !e.synced || len(e.lyrics) == 0 {
+		e.state = StateNoLyrics
+		e.index = -1
+		e.displayed = -1
+		return Step{Display: true, Index: -1}
+	}
+
+	position := e.clock.Position()
+	rate := e.clock.Rate()
+	playing := e.clock.Playing()
+	moving := playing && rate > 0
+
+	// While practicing, the playback goes back to the start of the loop
+	// as soon as it passes the end of the loop
+	if e.looping && moving && position >= e.loopEnd {
+		return Step{Index: e.index, LoopEnded: true}
+	}
+
+	e.index = e.lineAt(position)
+
+	next := unreachableTimestamp
+	if e.index+1 < len(e.lyrics) {
+		next = e.lyrics[e.index+1].Time + e.offset
+	}
+
+	step := Step{Index: e.index}
+	if e.outro && e.index == len(e.lyrics)-1 {
+		if outroStart := e.lyrics[e.index].Time + e.offset + e.outroAfter; position >= outroStart {
+			step.Index = len(e.lyrics)
+		} else {
+			next = outroStart
+		}
+	}
+
+	// Before the first line there is nothing to compare, so it's always displayed.
+	// Line changes during a pause are only displayed on demand.
+	if step.Index == -1 || e.displayed == -2 || (playing && e.displayed != step.Index) {
+		step.Display = true
+		e.displayed = step.Index
+	}
+
+	if !moving {
+		e.state = StateStill
+		return step
+	}
+	e.state = StateFollowing
+
+	if e.looping {
+		next = min(next, e.loopEnd)
+	}
+
+	// The distance to the next line is in song time, so it's scaled by the rate
+	// to get the real time. An extra millisecond makes sure the tick doesn't happen
+	// a hair before the line due to rounding.
+	step.Wait = time.Duration(max(next-position, 0)/rate*float64(time.Second)) + time.Millisecond
+	return step
+}
+
+// lineAt returns the index of the last lyric line that starts at the position or before it,
+// or -1 if there is none.
+func (e *Engine) lineAt(position float64) int {
+	return sort.Search(len(e.lyrics), func(i int) bool {
+		return e.lyrics[i].Time+e.offset > position
+	}) - 1
+}
+
+// sameLyrics tells whether the two slices are the very same lyrics (not just equal ones)
+func sameLyrics(a, b []structs.Lyric) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -2,35 +2,42 @@ package sync
 
 import (
 	"errors"
-	"time"
+	"sync/atomic"
 
 	"lrcsnc/internal/lyrics"
-	"lrcsnc/internal/output"
 	errs "lrcsnc/internal/pkg/errors"
 	"lrcsnc/internal/pkg/global"
 )
 
 var songChanged chan bool = make(chan bool)
-var lastDownloadStart time.Time
+var refetchRequested chan bool = make(chan bool)
+
+// fetchGeneration changes on each fetch, so the late results of the previous ones
+// can be told apart and dropped
+var fetchGeneration atomic.Uint64
 
 func lyricFetcher() {
 	for {
-		<-songChanged
+		// A refetch is the same as a song change, except the cache is skipped
+		fetch := lyrics.Fetch
+		select {
+		case <-songChanged:
+		case <-refetchRequested:
+			fetch = lyrics.Refetch
+		}
 
 		// This value will change on each new song changed event
 		// so if the download takes too long and the song was switched
 		// it can just store the necessary data in cache and forget about it
-		lastDownloadStart = time.Now()
+		generation := fetchGeneration.Add(1)
 
 		go func() {
-			thisDownloadStart := lastDownloadStart
-
-			lyricsData, err := lyrics.Fetch()
+			lyricsData, err := fetch()
 			if err != nil && !errors.Is(err, errs.ErrLyricsNotFound) {
 				return
 			}
 
-			if thisDownloadStart != lastDownloadStart {
+			if generation != fetchGeneration.Load() {
 				return
 			}
 
@@ -40,7 +47,7 @@ func lyricFetcher() {
 			global.Player.P.Song.LyricsData = lyricsData
 			global.Player.M.Unlock()
 
-			go output.Controllers[global.Config.C.Output.Type].OnPlayerUpdate()
+			go currentOutput().OnPlayerUpdate()
 
 			// And finally, it ends with a position sync
 			AskForPositionSync()
@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+
+	"lrcsnc/internal/mpris"
+	"lrcsnc/internal/offset"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// syncedLyrics returns a copy of the current song's lyrics
+// or an error if they are not synced
+func syncedLyrics() ([]structs.Lyric, error) {
+	global.Player.M.Lock()
+	defer global.Player.M.Unlock()
+
+	if global.Player.P.Song.LyricsData.LyricsState != types.LyricsStateSynced {
+		return nil, errs.ErrNoSyncedLyrics
+	}
+
+	return global.Player.P.Song.LyricsData.Lyrics, nil
+}
+
+// seekToLine seeks the player to the start of the lyric line with the index,
+// respecting the timestamp offset and the song's own one.
+func seekToLine(index int) error {
+	lyrics, err := syncedLyrics()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(lyrics) {
+		return errs.ErrLineNotFound
+	}
+
+	global.Player.M.Lock()
+	songOffset := global.Player.P.Song.LyricsData.Offset
+	global.Player.M.Unlock()
+
+	pos := max(lyrics[index].Time+offset.Get()+songOffset, 0)
+	log.Debug("sync/seekToLine", fmt.Sprintf("Seeking to line %d (%.2fs)", index, pos))
+
+	return seekTo(pos)
+}
+
+// seekBy seeks the player by d seconds (backwards if negative), or to the position d
+// if relative is false, keeping the position within the song.
+func seekBy(d float64, relative bool) error {
+	global.Player.M.Lock()
+	isStopped := global.Player.P.PlaybackStatus == mprislib.PlaybackStopped
+	duration := global.Player.P.Song.Duration
+	global.Player.M.Unlock()
+
+	if isStopped {
+		return errs.ErrNothingPlaying
+	}
+
+	pos := d
+	if relative {
+		pos += clock.Position()
+	}
+	if duration > 0 {
+		pos = min(pos, duration)
+	}
+	pos = max(pos, 0)
+	log.Debug("sync/seekBy", fmt.Sprintf("Seeking to %.2fs", pos))
+
+	return seekTo(pos)
+}
+
+// seekTo seeks the player to the position
+func seekTo(pos float64) error {
+	if err := mpris.SetPosition(pos); err != nil {
+		return err
+	}
+
+	// Some players don't send the Seeked signal when the position is set through MPRIS,
+	// so the position is applied right away instead of waiting for it
+	global.Player.M.Lock()
+	global.Player.P.Position = pos
+	global.Player.M.Unlock()
+	clock.Anchor(pos)
+	resyncLyrics()
+
+	return nil
+}
+
+// seekToRelativeLine seeks the player to the non-empty lyric line
+// that is delta lines away from the current one.
+func seekToRelativeLine(delta int) error {
+	lyrics, err := syncedLyrics()
+	if err != nil {
+		return err
+	}
+
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+
+	index := currentLine()
+	for remaining := delta * step; remaining > 0; remaining-- {
+		index += step
+		for index >= 0 && index < len(lyrics) && strings.TrimSpace(lyrics[index].Text) == "" {
+			index += step
+		}
+		if index < 0 || index >= len(lyrics) {
+			return errs.ErrLineNotFound
+		}
+	}
+
+	return seekToLine(index)
+}
+
+// seekToPhrase seeks the player to the first lyric line that contains the phrase.
+// The search is case-insensitive.
+func seekToPhrase(phrase string) error {
+	lyrics, err := syncedLyrics()
+	if err != nil {
+		return err
+	}
+
+	phrase = strings.ToLower(phrase)
+	for i, l := range lyrics {
+		if strings.Contains(strings.ToLower(l.Text), phrase) {
+			return seekToLine(i)
+		}
+	}
+
+	return errs.ErrLineNotFound
+}
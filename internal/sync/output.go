@@ -1,68 +1,168 @@
 package sync
 
 import (
-	"math"
 	"time"
 
+	"lrcsnc/internal/offset"
 	"lrcsnc/internal/output"
 	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
 	"lrcsnc/internal/pkg/types"
-
-	"github.com/Endg4meZer0/go-mpris"
+	"lrcsnc/internal/practice"
 )
 
-var lyricsTimer = time.NewTimer(5 * time.Minute)
-var lyricIndex = -1
-var writtenTimestamp float64
+// The lyrics synchronizer is the only owner of the engine.
+// Everything else talks to it through these channels.
+var (
+	// resyncRequested makes the engine look at the clock again
+	resyncRequested = make(chan bool, 1)
+	// redisplayRequested makes the engine display the current line again
+	redisplayRequested = make(chan bool, 1)
+	// engineQueries are run by the lyrics synchronizer with the engine
+	engineQueries = make(chan func(e *Engine))
+)
 
+// resyncLyrics makes the lyrics synchronizer look at the clock again
+// (e.g. after a seek or a change of the offset or the playback state)
 func resyncLyrics() {
-	lyricsTimer.Reset(1)
-}
-
-func stopLyricsSync() {
-	lyricsTimer.Stop()
+	select {
+	case resyncRequested <- true:
+	default:
+		// A resync is already pending
+	}
 }
 
+// outputUpdate makes the lyrics synchronizer display the current line again
+// (e.g. after a change of the playback status that the output shows)
 func outputUpdate() {
-	if global.Player.P.Song.LyricsData.LyricsState != types.LyricsStateSynced {
-		output.Controllers[global.Config.C.Output.Type].DisplayLyric(-1)
-	} else {
-		output.Controllers[global.Config.C.Output.Type].DisplayLyric(lyricIndex)
+	select {
+	case redisplayRequested <- true:
+	default:
+		// A redisplay is already pending
 	}
 }
 
+// currentLine returns the index of the current lyric line, or -1 if there is none.
+//
+// It must NOT be called from the lyrics synchronizer itself.
+func currentLine() int {
+	index := make(chan int)
+	engineQueries <- func(e *Engine) { index <- e.Index() }
+	return <-index
+}
+
+// currentOutput returns the controller that passes everything on to all the outputs
+func currentOutput() output.Controller {
+	return output.All()
+}
+
+// tickInterval returns how often the output should get the position while playing
+func tickInterval() time.Duration {
+	global.Config.M.Lock()
+	defer global.Config.M.Unlock()
+
+	return time.Duration(global.Config.C.Output.TickInterval * float64(time.Second))
+}
+
+// This is a lyrics synchronizer.
+// It owns the sync engine and ticks it whenever the engine asks for it
+// or something that affects the lyrics changes, then displays what the engine decided.
 func lyricsSynchronizer() {
+	e := NewEngine(clock)
+	timer := time.NewTimer(time.Minute)
+	timer.Stop() // the timer should not fire just yet
+
+	for {
+		resynced := false
+		select {
+		case <-timer.C:
+		case <-resyncRequested:
+			resynced = true
+		case <-redisplayRequested:
+			e.Redisplay()
+		case q := <-engineQueries:
+			q(e)
+			continue
+		}
+
+		step := tickEngine(e)
+		tick := tickInterval()
+		ticking := tick > 0 && clock.Playing()
+		// After a seek or a change of the rate, the output may show something
+		// that depends on the position even if the line is the same
+		positionChanged := ticking || resynced
+
+		if step.Display || positionChanged {
+			global.Player.M.Lock()
+			global.Player.P.Position = clock.Position()
+			global.Player.M.Unlock()
+		}
+
+		if step.Display {
+			currentOutput().DisplayLyric(step.Index)
+		} else if positionChanged {
+			currentOutput().OnPositionUpdate()
+		}
+
+		wait := step.Wait
+		if ticking && (wait <= 0 || wait > tick) {
+			wait = tick
+		}
+		if wait > 0 {
+			timer.Reset(wait)
+		} else {
+			timer.Stop()
+		}
+	}
+}
+
+// tickEngine feeds the engine the current lyrics, offset, outro and practice loop, and ticks it.
+// If the playback has passed the end of the practice loop, it is sent back
+// to the loop's start and the engine is ticked again.
+func tickEngine(e *Engine) Step {
 	for {
-		<-lyricsTimer.C
-		if global.Player.P.Song.LyricsData.LyricsState != types.LyricsStateSynced {
-			output.Controllers[global.Config.C.Output.Type].DisplayLyric(-1)
+		timestampOffset := offset.Get()
+		l, looping := practice.Get()
+
+		global.Config.M.Lock()
+		outro := global.Config.C.Output.Outro
+		global.Config.M.Unlock()
+
+		global.Player.M.Lock()
+		song := global.Player.P.Song
+		global.Player.M.Unlock()
+
+		e.SetLyrics(song.LyricsData.Lyrics, song.LyricsData.LyricsState == types.LyricsStateSynced)
+		e.SetOffset(timestampOffset + song.LyricsData.Offset)
+		if outro.Behaviour == types.OutroClear || outro.Behaviour == types.OutroEnd {
+			e.SetOutro(outro.After)
+		} else {
+			e.ClearOutro()
+		}
+		loopEnd := PracticeLoopEnd(l, song, timestampOffset+song.LyricsData.Offset)
+		if looping {
+			e.SetLoopEnd(loopEnd)
 		} else {
-			// 5999.99s is basically the maximum limit of .lrc files' timestamps AFAIK, so 6000s is unreachable
-			currentLyricTimestamp := -1.0
-			nextLyricTimestamp := 6000.0
-			newLyricIndex := -1
-
-			for i, lyric := range global.Player.P.Song.LyricsData.Lyrics {
-				if lyric.Time+global.Config.C.Lyrics.TimestampOffset <= global.Player.P.Position && currentLyricTimestamp <= lyric.Time+global.Config.C.Lyrics.TimestampOffset {
-					currentLyricTimestamp = lyric.Time + global.Config.C.Lyrics.TimestampOffset
-					newLyricIndex = i
-				}
-			}
-
-			if newLyricIndex != len(global.Player.P.Song.LyricsData.Lyrics)-1 {
-				nextLyricTimestamp = global.Player.P.Song.LyricsData.Lyrics[newLyricIndex+1].Time + global.Config.C.Lyrics.TimestampOffset
-			}
-
-			lyricsTimerDuration := time.Duration(int64(math.Abs(nextLyricTimestamp-global.Player.P.Position)*1000)) * time.Millisecond
-
-			if currentLyricTimestamp == -1 || (global.Player.P.PlaybackStatus == mpris.PlaybackPlaying && writtenTimestamp != currentLyricTimestamp) {
-				output.Controllers[global.Config.C.Output.Type].DisplayLyric(newLyricIndex)
-			}
-
-			lyricIndex = newLyricIndex
-			writtenTimestamp = currentLyricTimestamp
-			global.Player.P.Position = nextLyricTimestamp
-			lyricsTimer.Reset(lyricsTimerDuration)
+			e.ClearLoop()
+		}
+
+		step := e.Tick()
+		if !step.LoopEnded {
+			return step
+		}
+
+		practice.CountLoop()
+		if err := seekToLine(l.Start); err != nil {
+			log.Warn("sync/lyricsSynchronizer", "Couldn't go back to the start of the practice loop, stopping it: "+err.Error())
+			stopPracticeLoop()
+		} else if clock.Position() >= loopEnd {
+			// Going back didn't leave the loop's end behind (e.g. the offset has changed since the loop started),
+			// so ticking again would only seek over and over again
+			log.Warn("sync/lyricsSynchronizer", "The start of the practice loop is past its end, stopping it")
+			stopPracticeLoop()
 		}
+		// Forces the start line to be displayed again with the new loop count
+		// (matters if the loop is a single line)
+		e.Redisplay()
 	}
 }
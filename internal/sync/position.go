@@ -1,71 +1,65 @@
 package sync
 
 import (
-	"log"
 	"time"
 
 	"lrcsnc/internal/mpris"
 	"lrcsnc/internal/pkg/global"
-
-	mprislib "github.com/Endg4meZer0/go-mpris"
+	"lrcsnc/internal/pkg/log"
 )
 
 var needsSynchronization chan bool = make(chan bool, 1)
-var isSynchronizing bool = false
 
 // This is a position synchronizer.
-// It is triggered by AskForPositionSync function 
+// It is triggered by AskForPositionSync function
 // on any Seeked signals, PlaybackStatus changes and the lyrics fetching.
-// 
-// It is needed for more precise syncing of the lyrics
+//
+// It reads the player's position once and corrects the playback clock with it
 // (to prevent a possible mismatch of the position from our data
 // and the actual player's position).
 //
-// To prevent multiple synchronizations from taking place at the same time
+// Players that report the position in whole seconds need a few more readings
+// to reconstruct the sub-second part. Instead of polling, the clock tells
+// when the next reading is the most informative, and only then it is taken.
+//
+// To prevent multiple synchronizations from piling up
 // the `needsSynchronization` channel is buffered at 1.
 func positionSynchronizer() {
-	ticker := time.NewTicker(50 * time.Millisecond) // 0.05 seconds as delta time for sync
-	ticker.Stop()                                   // ticker should not fire just yet
+	refinementTimer := time.NewTimer(time.Minute)
+	refinementTimer.Stop() // the timer should not fire just yet
 	for {
-		<-needsSynchronization
-
-		if global.Player.P.PlaybackStatus != mprislib.PlaybackPlaying {
-			stopLyricsSync()
-			continue
+		select {
+		case <-needsSynchronization:
+		case <-refinementTimer.C:
 		}
 
-		isSynchronizing = true
-		oldPos, err := mpris.GetPosition()
+		// The reading is considered to be taken in the middle of the D-Bus round trip
+		requestTime := time.Now()
+		pos, err := mpris.GetPosition()
 		if err != nil {
-			// TODO: logger
-			log.Println(err)
+			log.Error("sync/positionSynchronizer", "Couldn't get the position: "+err.Error())
 			continue
 		}
-		ticker.Reset(50 * time.Millisecond)
-		for {
-			<-ticker.C
-			newPos, err := mpris.GetPosition()
-			if err != nil {
-				// TODO: logger
-				log.Println(err)
-				break
-			}
-			if newPos != oldPos {
-				global.Player.M.Lock()
-				global.Player.P.Position = newPos
-				global.Player.M.Unlock()
+		readingTime := requestTime.Add(time.Since(requestTime) / 2)
 
-				break
-			}
+		if delay, ok := clock.Observe(pos, readingTime); ok {
+			refinementTimer.Reset(delay)
+		} else {
+			refinementTimer.Stop()
 		}
-		ticker.Stop()
+
+		global.Player.M.Lock()
+		global.Player.P.Position = clock.Position()
+		global.Player.M.Unlock()
+
 		resyncLyrics()
-		isSynchronizing = false
 	}
 }
 
 func AskForPositionSync() {
-	if !isSynchronizing {
-		needsSynchronization <- true
+	select {
+	case needsSynchronization <- true:
+	default:
+		// A synchronization is already pending
 	}
 }
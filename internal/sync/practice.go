@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"fmt"
+	"sync"
+
+	"lrcsnc/internal/mpris"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/practice"
+)
+
+// loopEndMargin is how long before the end of the song (in seconds)
+// a practice loop including the last line ends
+const loopEndMargin = 0.5
+
+// rateBeforeLoop is the playback rate to restore when a practice loop
+// that changed the rate is stopped
+var rateBeforeLoop = struct {
+	M sync.Mutex
+	V float64
+}{V: 1}
+
+// startPracticeLoop starts looping the range of lyric lines.
+// If the loop has a rate set, the player's rate is changed too (if the player supports it).
+func startPracticeLoop(l practice.Loop) error {
+	lyrics, err := syncedLyrics()
+	if err != nil {
+		return err
+	}
+	if l.Start < 0 || l.End < l.Start {
+		return errs.ErrInvalidCommandData
+	}
+	if l.End >= len(lyrics) {
+		return errs.ErrLineNotFound
+	}
+
+	// A loop that ends where it starts (e.g. a line sharing its timestamp with the next one)
+	// would send the playback back to its start over and over again
+	global.Player.M.Lock()
+	song := global.Player.P.Song
+	global.Player.M.Unlock()
+	if PracticeLoopEnd(l, song, 0) <= lyrics[l.Start].Time {
+		return errs.ErrEmptyLoop
+	}
+
+	// A previous loop could have changed the rate, so it's stopped properly first
+	stopPracticeLoop()
+
+	if l.Rate != 0 {
+		global.Player.M.Lock()
+		rate := global.Player.P.Rate
+		global.Player.M.Unlock()
+
+		rateBeforeLoop.M.Lock()
+		rateBeforeLoop.V = rate
+		rateBeforeLoop.M.Unlock()
+
+		if err := mpris.SetRate(l.Rate); err != nil {
+			log.Warn("sync/startPracticeLoop", "Couldn't change the playback rate, looping without it: "+err.Error())
+			l.Rate = 0
+		}
+	}
+
+	practice.Start(l)
+	log.Info("sync/startPracticeLoop", fmt.Sprintf("Looping lines %d-%d", l.Start, l.End))
+
+	if index := currentLine(); index < l.Start || index > l.End {
+		return seekToLine(l.Start)
+	}
+	outputUpdate()
+
+	return nil
+}
+
+// stopPracticeLoop stops the active practice loop (if any) and restores the playback rate.
+func stopPracticeLoop() {
+	l, wasActive := practice.Stop()
+	if !wasActive {
+		return
+	}
+
+	if l.Rate != 0 {
+		rateBeforeLoop.M.Lock()
+		rate := rateBeforeLoop.V
+		rateBeforeLoop.M.Unlock()
+
+		if err := mpris.SetRate(rate); err != nil {
+			log.Warn("sync/stopPracticeLoop", "Couldn't restore the playback rate: "+err.Error())
+		}
+	}
+
+	log.Info("sync/stopPracticeLoop", "Stopped looping")
+	outputUpdate()
+}
+
+// PracticeLoopEnd returns the position at which the practice loop goes back to its start:
+// it's the start of the line after the range or, if there is none,
+// a bit before the end of the song (the player would end or change the track otherwise).
+//
+// It does NOT lock the player mutex.
+func PracticeLoopEnd(l practice.Loop, song structs.Song, timestampOffset float64) float64 {
+	if l.End+1 < len(song.LyricsData.Lyrics) {
+		return song.LyricsData.Lyrics[l.End+1].Time + timestampOffset
+	}
+	if song.Duration != 0 {
+		return song.Duration - loopEndMargin
+	}
+	return unreachableTimestamp
+}
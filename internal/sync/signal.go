@@ -3,7 +3,7 @@ package sync
 import (
 	"fmt"
 	"lrcsnc/internal/mpris"
-	"lrcsnc/internal/output"
+	"lrcsnc/internal/offset"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/log"
 
@@ -15,25 +15,40 @@ func mprisMessageReceiver() {
 		log.Debug("sync/mprisMessageReceiver", fmt.Sprintf("Received message: %v", msg))
 		switch msg.Type {
 		case mpris.SignalReady, mpris.SignalPlayerChanged:
-			if global.Player.P.PlaybackStatus != mprislib.PlaybackStopped {
+			// The player data was just gathered from scratch
+			global.Player.M.Lock()
+			clock.Restart(global.Player.P.Position, global.Player.P.Rate, global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying)
+			playerName := global.Player.P.Name
+			isStopped := global.Player.P.PlaybackStatus == mprislib.PlaybackStopped
+			global.Player.M.Unlock()
+
+			// Another player may have another offset profile
+			offset.SetPlayer(playerName)
+			currentOutput().OnConfigUpdate()
+
+			if !isStopped {
 				songChanged <- true
 			} else {
-				output.Controllers[global.Config.C.Output.Type].DisplayLyric(-1)
+				currentOutput().DisplayLyric(-1)
 			}
 		case mpris.SignalSeeked:
 			// On seeked signal we just update the position...
 			global.Player.M.Lock()
-			global.Player.P.Position = msg.Data.(float64) / 1000 / 1000
+			global.Player.P.Position = msg.Data.(float64)
 			global.Player.M.Unlock()
+			clock.Reset(msg.Data.(float64))
 			// ...and, of course, ask for a position sync
 			AskForPositionSync()
 		case mpris.SignalPlaybackStatusChanged:
 			// If the playback status has changed...
 			global.Player.M.Lock()
 			global.Player.P.PlaybackStatus = msg.Data.(mprislib.PlaybackStatus)
+			// ...the clock stops or continues counting from the position reached...
+			clock.SetPlaying(global.Player.P.PlaybackStatus == mprislib.PlaybackPlaying)
 			// ...and if it's stopped then also reset the position
 			if global.Player.P.PlaybackStatus == mprislib.PlaybackStopped {
 				global.Player.P.Position = 0
+				clock.Anchor(0)
 			}
 			global.Player.M.Unlock()
 
@@ -47,8 +62,10 @@ func mprisMessageReceiver() {
 			global.Player.M.Lock()
 			global.Player.P.Rate = msg.Data.(float64)
 			global.Player.M.Unlock()
-			// ...we will absolutely ask for a sync, since the old sync is now invalid
-			AskForPositionSync()
+			// ...the position reached with the old rate becomes the new anchor for the clock...
+			clock.SetRate(msg.Data.(float64))
+			// ...and the timer of the current line is rescheduled with the new rate
+			resyncLyrics()
 
 		case mpris.SignalMetadataChanged:
 			// If the metadata has changed...
@@ -58,9 +75,12 @@ func mprisMessageReceiver() {
 				log.Error("sync/mprisMessageReceiver", "Couldn't parse metadata: "+err.Error())
 			}
 			global.Player.M.Unlock()
+			// A practice loop makes no sense for another song
+			stopPracticeLoop()
+
 			// Now we can send a signal to the output module
 			// that the info has changed
-			output.Controllers[global.Config.C.Output.Type].OnPlayerUpdate()
+			currentOutput().OnPlayerUpdate()
 
 			// And also send a signal that the song has changed and we need
 			// to fetch some new lyrics
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"sync"
+
+	"lrcsnc/internal/audio"
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/log"
+)
+
+// sinkWatch holds the channel that stops the running audio sink watcher,
+// or nil if it's not running
+var sinkWatch = struct {
+	M    sync.Mutex
+	Done chan bool
+}{}
+
+// updateSinkWatcher starts or stops the audio sink watcher
+// according to whether the audio sink detection is enabled.
+// It's called on start and on every config reload.
+func updateSinkWatcher() {
+	global.Config.M.Lock()
+	enabled := global.Config.C.Lyrics.OffsetProfiles.DetectSink
+	global.Config.M.Unlock()
+
+	sinkWatch.M.Lock()
+	defer sinkWatch.M.Unlock()
+
+	switch {
+	case enabled && sinkWatch.Done == nil:
+		sinkWatch.Done = make(chan bool)
+		go sinkWatcher(sinkWatch.Done)
+	case !enabled && sinkWatch.Done != nil:
+		close(sinkWatch.Done)
+		sinkWatch.Done = nil
+		// The sink's offset profile doesn't apply without the detection
+		offset.SetSink("")
+	}
+}
+
+// This is an audio sink watcher.
+// It keeps the offset profiles in line with the default audio sink
+// (e.g. when Bluetooth headphones connect) until done is closed.
+func sinkWatcher(done chan bool) {
+	sinks := make(chan string)
+	go func() {
+		err := audio.WatchSink(sinks, done)
+		select {
+		case <-done:
+			log.Info("sync/sinkWatcher", "Stopped watching the audio sink")
+		default:
+			if err != nil {
+				log.Error("sync/sinkWatcher", "Stopped watching the audio sink: "+err.Error())
+			} else {
+				log.Warn("sync/sinkWatcher", "The sound server stopped reporting events, the audio sink is not watched anymore")
+			}
+		}
+	}()
+
+	// If the sound server couldn't be watched at all, the channel is closed right away
+	first := true
+	for sink := range sinks {
+		// A sink found while stopping must not undo the reset,
+		// so it's checked under the same lock the stopping is done under
+		sinkWatch.M.Lock()
+		stopping := sinkWatch.Done != done
+		if !stopping {
+			offset.SetSink(sink)
+		}
+		sinkWatch.M.Unlock()
+		if stopping {
+			continue
+		}
+
+		// The first sink is the one the watching starts with, so there is nothing to notify about
+		if first {
+			first = false
+			resyncLyrics()
+			continue
+		}
+		log.Info("sync/sinkWatcher", "The audio sink is now "+sink)
+		offsetChanged()
+	}
+
+	// A config reload may try watching again
+	sinkWatch.M.Lock()
+	if sinkWatch.Done == done {
+		sinkWatch.Done = nil
+	}
+	sinkWatch.M.Unlock()
+}
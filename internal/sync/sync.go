@@ -4,6 +4,12 @@ func Start() {
 	// Goroutine of the position synchronizer
 	go positionSynchronizer()
 
+	// Goroutine of the drift checker
+	go driftChecker()
+
+	// Goroutine of the audio sink watcher for offset profiles (if enabled)
+	updateSinkWatcher()
+
 	// Goroutine to watch for DBus signals
 	go mprisMessageReceiver()
 
@@ -12,4 +18,7 @@ func Start() {
 
 	// Goroutine to actively synchronize the lyrics with the song
 	go lyricsSynchronizer()
+
+	// Goroutine to handle the commands from control surfaces
+	go controlCommandReceiver()
 }
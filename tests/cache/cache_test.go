@@ -1,7 +1,9 @@
 package cache_test
 
 import (
+	"errors"
 	"lrcsnc/internal/cache"
+	errs "lrcsnc/internal/pkg/errors"
 	"lrcsnc/internal/pkg/global"
 	"lrcsnc/internal/pkg/structs"
 	"lrcsnc/internal/pkg/types"
@@ -54,3 +56,47 @@ func TestStoreGetCycle(t *testing.T) {
 		)
 	}
 }
+
+func TestStoreOffset(t *testing.T) {
+	global.Config.C.Cache.Dir = t.TempDir()
+	global.Config.C.Cache.Enabled = true
+	global.Config.C.Cache.LifeSpan = 0
+	testSong := structs.Song{
+		Title:    "Is This Late?",
+		Artists:  []string{"Endg4me_"},
+		Album:    "lrcsnc",
+		Duration: 12.12,
+		LyricsData: structs.LyricsData{
+			Lyrics: []structs.Lyric{
+				{Time: 4.12, Text: "Pam-pam-pampararam"},
+			},
+			LyricsState: types.LyricsStateSynced,
+		},
+	}
+
+	// Without an entry the song is stored whole
+	testSong.LyricsData.Offset = -0.5
+	if err := cache.StoreOffset(&testSong); err != nil {
+		t.Fatalf("[tests/cache/TestStoreOffset] ERROR: Failed to store the offset without a cache entry: %v", err)
+	}
+	answer, cacheState := cache.Fetch(&testSong)
+	if cacheState != cache.CacheStateActive || len(answer.Lyrics) != 1 || answer.Offset != -0.5 {
+		t.Errorf("[tests/cache/TestStoreOffset] ERROR: Expected the whole song with the offset of -0.5 to be stored, got %v (%v)", answer, cacheState)
+	}
+
+	// With an entry only the offset is changed
+	testSong.LyricsData.Lyrics = nil
+	testSong.LyricsData.Offset = 0.25
+	if err := cache.StoreOffset(&testSong); err != nil {
+		t.Fatalf("[tests/cache/TestStoreOffset] ERROR: Failed to store the offset in the cache entry: %v", err)
+	}
+	answer, _ = cache.Fetch(&testSong)
+	if len(answer.Lyrics) != 1 || answer.Offset != 0.25 {
+		t.Errorf("[tests/cache/TestStoreOffset] ERROR: Expected the cached lyrics to stay with the offset of 0.25, got %v", answer)
+	}
+
+	global.Config.C.Cache.Enabled = false
+	if err := cache.StoreOffset(&testSong); !errors.Is(err, errs.ErrCacheDisabled) {
+		t.Errorf("[tests/cache/TestStoreOffset] ERROR: Expected an error with the cache disabled, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package control_test
+
+import (
+	"errors"
+	"testing"
+
+	"lrcsnc/internal/control"
+	errs "lrcsnc/internal/pkg/errors"
+	"lrcsnc/internal/practice"
+)
+
+// TestParse tests the conversion of textual commands
+// (as sent by `lrcsnc ctl`) into control commands.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		t    control.CommandType
+		data any
+		err  error
+	}{
+		{name: "status", args: []string{"status"}, t: control.CommandStatus},
+		{name: "status-extra-arg", args: []string{"status", "-j"}, err: errs.ErrInvalidCommandData},
+		{name: "offset-set", args: []string{"offset", "0.5"}, t: control.CommandSetOffset, data: 0.5},
+		{name: "offset-plus", args: []string{"offset", "+0.2"}, t: control.CommandAdjustOffset, data: 0.2},
+		{name: "offset-minus", args: []string{"offset", "-0.2"}, t: control.CommandAdjustOffset, data: -0.2},
+		{name: "offset-reset", args: []string{"offset", "reset"}, t: control.CommandResetOffset},
+		{name: "offset-song", args: []string{"offset", "song", "-0.5"}, t: control.CommandAdjustSongOffset, data: -0.5},
+		{name: "offset-song-reset", args: []string{"offset", "song", "reset"}, t: control.CommandSetSongOffset, data: 0.0},
+		{name: "seek", args: []string{"seek", "90"}, t: control.CommandSeek, data: 90.0},
+		{name: "seek-back", args: []string{"seek", "-5"}, t: control.CommandSeekRelative, data: -5.0},
+		{name: "offset-nan", args: []string{"offset", "soon"}, err: errs.ErrInvalidCommandData},
+		{name: "cache-drop", args: []string{"cache", "drop-current"}, t: control.CommandDropCache},
+		{name: "player-next", args: []string{"player", "next"}, t: control.CommandPlayer, data: control.PlayerNext},
+		{name: "player-unknown", args: []string{"player", "explode"}, err: errs.ErrInvalidCommandData},
+		{name: "line-prev", args: []string{"line", "prev"}, t: control.CommandSeekRelativeLine, data: -1},
+		{name: "line-index", args: []string{"line", "12"}, t: control.CommandSeekLine, data: 12},
+		{name: "line-negative", args: []string{"line", "-3"}, err: errs.ErrInvalidCommandData},
+		{name: "find", args: []string{"find", "hello", "world"}, t: control.CommandSeekPhrase, data: "hello world"},
+		{name: "find-empty", args: []string{"find"}, err: errs.ErrInvalidCommandData},
+		{name: "loop", args: []string{"loop", "2", "4"}, t: control.CommandStartLoop, data: practice.Loop{Start: 2, End: 4}},
+		{name: "loop-rate", args: []string{"loop", "2", "4", "0.75"}, t: control.CommandStartLoop, data: practice.Loop{Start: 2, End: 4, Rate: 0.75}},
+		{name: "loop-reversed", args: []string{"loop", "4", "2"}, err: errs.ErrInvalidCommandData},
+		{name: "loop-off", args: []string{"loop", "off"}, t: control.CommandStopLoop},
+		{name: "drift", args: []string{"drift"}, t: control.CommandDrift},
+		{name: "empty", args: []string{}, err: errs.ErrUnknownCommand},
+		{name: "unknown", args: []string{"dance"}, err: errs.ErrUnknownCommand},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotT, gotData, err := control.Parse(tt.args)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("[tests/control/parse/%v] Received error %v, want %v", tt.name, err, tt.err)
+				return
+			}
+			if err == nil && (gotT != tt.t || gotData != tt.data) {
+				t.Errorf("[tests/control/parse/%v] Received %v (%v), want %v (%v)", tt.name, gotT, gotData, tt.t, tt.data)
+			}
+		})
+	}
+}
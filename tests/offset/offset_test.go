@@ -0,0 +1,117 @@
+package offset_test
+
+import (
+	"math"
+	"path"
+	"testing"
+
+	"lrcsnc/internal/offset"
+	"lrcsnc/internal/pkg/global"
+)
+
+// TestProfiles tests that the offset profiles of the player and the audio sink
+// stack with the config's timestamp offset.
+func TestProfiles(t *testing.T) {
+	global.Config.C.Lyrics.TimestampOffset = 0.1
+	global.Config.C.Lyrics.OffsetProfiles.Players = map[string]float64{"spotify": -0.3, "fire": 0.5, "firefox": 0.2}
+	global.Config.C.Lyrics.OffsetProfiles.Sinks = map[string]float64{"bluez": 0.25}
+	_ = offset.Load("")
+
+	tests := []struct {
+		name   string
+		player string
+		sink   string
+		offset float64
+	}{
+		{name: "no-profiles", player: "cmus", sink: "alsa_output.pci-0000_00_1f.3.analog-stereo", offset: 0.1},
+		{name: "player", player: "Spotify", offset: -0.2},
+		{name: "longest-match", player: "Mozilla Firefox", offset: 0.3},
+		{name: "player-and-sink", player: "Spotify", sink: "bluez_output.00_11_22_33_44_55.1", offset: 0.05},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offset.SetPlayer(test.player)
+			offset.SetSink(test.sink)
+
+			if v := offset.Get(); math.Abs(v-test.offset) > 1e-9 {
+				t.Errorf("[tests/offset/profiles/%v] ERROR: Expected the offset to be %.2f, got %.2f", test.name, test.offset, v)
+			}
+		})
+	}
+}
+
+// TestStateFile tests that the runtime adjustments are kept per player and audio sink
+// and survive a restart through the state file.
+func TestStateFile(t *testing.T) {
+	global.Config.C.Lyrics.TimestampOffset = 0
+	global.Config.C.Lyrics.OffsetProfiles.Players = map[string]float64{"spotify": -0.3}
+	global.Config.C.Lyrics.OffsetProfiles.Sinks = nil
+	stateFile := path.Join(t.TempDir(), "state", "offsets.json")
+
+	if err := offset.Load(stateFile); err != nil {
+		t.Fatalf("[tests/offset/stateFile] ERROR: Failed to load a missing state file: %v", err)
+	}
+
+	offset.SetPlayer("Spotify")
+	offset.SetSink("bluez_output.1")
+	offset.Adjust(0.2)
+	offset.SetSink("alsa_output.1")
+	offset.Set(1)
+
+	if err := offset.Load(stateFile); err != nil {
+		t.Fatalf("[tests/offset/stateFile] ERROR: Failed to load the state file back: %v", err)
+	}
+
+	offset.SetSink("bluez_output.1")
+	if v := offset.Get(); math.Abs(v-(-0.1)) > 1e-9 {
+		t.Errorf("[tests/offset/stateFile] ERROR: Expected the offset with the Bluetooth sink to be -0.10, got %.2f", v)
+	}
+	offset.SetSink("alsa_output.1")
+	if v := offset.Get(); math.Abs(v-1) > 1e-9 {
+		t.Errorf("[tests/offset/stateFile] ERROR: Expected the offset with the other sink to be 1.00, got %.2f", v)
+	}
+
+	offset.Reset()
+	if v := offset.Get(); math.Abs(v-(-0.3)) > 1e-9 {
+		t.Errorf("[tests/offset/stateFile] ERROR: Expected the offset to go back to the profile's -0.30 after a reset, got %.2f", v)
+	}
+}
+
+// TestSwitchStateFile tests that switching to another state file reads the adjustments from it
+// and saves the later ones there instead of the old file.
+func TestSwitchStateFile(t *testing.T) {
+	global.Config.C.Lyrics.TimestampOffset = 0
+	global.Config.C.Lyrics.OffsetProfiles.Players = nil
+	global.Config.C.Lyrics.OffsetProfiles.Sinks = nil
+	dir := t.TempDir()
+	first, second := path.Join(dir, "first.json"), path.Join(dir, "second.json")
+
+	_ = offset.Load(first)
+	offset.SetPlayer("cmus")
+	offset.SetSink("")
+	offset.Set(1)
+
+	// A missing file keeps the current adjustments
+	if err := offset.SetStateFile(second); err != nil {
+		t.Fatalf("[tests/offset/switchStateFile] ERROR: Failed to switch to a missing state file: %v", err)
+	}
+	if v := offset.Get(); math.Abs(v-1) > 1e-9 {
+		t.Errorf("[tests/offset/switchStateFile] ERROR: Expected the offset to stay 1.00, got %.2f", v)
+	}
+	offset.Set(2)
+
+	if err := offset.SetStateFile(first); err != nil {
+		t.Fatalf("[tests/offset/switchStateFile] ERROR: Failed to switch back to the first state file: %v", err)
+	}
+	if v := offset.Get(); math.Abs(v-1) > 1e-9 {
+		t.Errorf("[tests/offset/switchStateFile] ERROR: Expected the first state file to keep 1.00, got %.2f", v)
+	}
+
+	if err := offset.SetStateFile(second); err != nil {
+		t.Fatalf("[tests/offset/switchStateFile] ERROR: Failed to switch to the second state file: %v", err)
+	}
+	if v := offset.Get(); math.Abs(v-2) > 1e-9 {
+		t.Errorf("[tests/offset/switchStateFile] ERROR: Expected the second state file to have 2.00, got %.2f", v)
+	}
+}
@@ -0,0 +1,171 @@
+package http_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	lrchttp "lrcsnc/internal/output/http"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+var testLyrics = []structs.Lyric{
+	{Time: 1, Text: "One"},
+	{Time: 2, Text: ""},
+	{Time: 3, Text: "Two"},
+	{Time: 4, Text: "Three"},
+	{Time: 5, Text: "Four"},
+}
+
+// setup starts the output on a free local port with the context
+// and makes the player play a song with the lyrics
+func setup(t *testing.T, context structs.ContextOutputConfig) (*lrchttp.Output, string) {
+	// The port is taken to find a free one and given back to the output
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("[tests/output/http] ERROR: Failed to find a free port: %v", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	global.Config.M.Lock()
+	global.Config.C.Output.Instances = nil
+	global.Config.C.Output.Type = types.OutputHTTP
+	global.Config.C.Output.HTTP = structs.HTTPOutputConfig{Address: address, Context: context}
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	global.Player.P.Name = "fake"
+	global.Player.P.Song = structs.Song{
+		Title:   "Song",
+		Artists: []string{"Artist"},
+		LyricsData: structs.LyricsData{
+			Lyrics:      testLyrics,
+			LyricsState: types.LyricsStateSynced,
+		},
+	}
+	global.Player.M.Unlock()
+
+	o, err := lrchttp.New("default")
+	if err != nil {
+		t.Fatalf("[tests/output/http] ERROR: Failed to create the output: %v", err)
+	}
+	t.Cleanup(o.Close)
+	return o, "http://" + address
+}
+
+// TestSnapshot tests that the snapshot has the current line with the lines around it.
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    int
+		context  structs.ContextOutputConfig
+		line     string
+		previous []string
+		next     []string
+	}{
+		{name: "before-lyrics", index: -1, context: structs.ContextOutputConfig{Previous: 1, Next: 2}, line: "", previous: []string{}, next: []string{"One", "Two"}},
+		{name: "middle", index: 2, context: structs.ContextOutputConfig{Previous: 1, Next: 1}, line: "Two", previous: []string{"One"}, next: []string{"Three"}},
+		{name: "last", index: 4, context: structs.ContextOutputConfig{Previous: 2, Next: 2}, line: "Four", previous: []string{"Two", "Three"}, next: []string{}},
+		{name: "no-context", index: 3, line: "Three", previous: []string{}, next: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o, url := setup(t, test.context)
+			o.DisplayLyric(test.index)
+
+			resp, err := http.Get(url + "/snapshot")
+			if err != nil {
+				t.Fatalf("[tests/output/http/snapshot/%v] ERROR: Failed to get the snapshot: %v", test.name, err)
+			}
+			defer resp.Body.Close()
+
+			var s lrchttp.Snapshot
+			if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+				t.Fatalf("[tests/output/http/snapshot/%v] ERROR: Failed to parse the snapshot: %v", test.name, err)
+			}
+			if s.Index != test.index || s.Line != test.line {
+				t.Errorf("[tests/output/http/snapshot/%v] ERROR: Expected the line %d '%s', got %d '%s'", test.name, test.index, test.line, s.Index, s.Line)
+			}
+			if !slices.Equal(s.Previous, test.previous) || !slices.Equal(s.Next, test.next) {
+				t.Errorf("[tests/output/http/snapshot/%v] ERROR: Expected the context %q/%q, got %q/%q", test.name, test.previous, test.next, s.Previous, s.Next)
+			}
+			if s.Title != "Song" || !slices.Equal(s.Artists, []string{"Artist"}) || s.Player != "fake" {
+				t.Errorf("[tests/output/http/snapshot/%v] ERROR: Expected the song's data, got %+v", test.name, s)
+			}
+		})
+	}
+}
+
+// TestEvents tests that the stream starts with the current state and then follows the changes.
+func TestEvents(t *testing.T) {
+	o, url := setup(t, structs.ContextOutputConfig{})
+	o.DisplayLyric(0)
+
+	resp, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatalf("[tests/output/http/events] ERROR: Failed to connect to the stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("[tests/output/http/events] ERROR: Expected an event stream, got '%s'", ct)
+	}
+
+	events := make(chan [2]string)
+	go func() {
+		defer close(events)
+		r := bufio.NewReader(resp.Body)
+		var name string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if n, ok := strings.CutPrefix(line, "event: "); ok {
+				name = n
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				events <- [2]string{name, data}
+			}
+		}
+	}()
+	next := func() (string, string) {
+		select {
+		case e := <-events:
+			return e[0], e[1]
+		case <-time.After(2 * time.Second):
+			return "", ""
+		}
+	}
+
+	name, data := next()
+	var s lrchttp.Snapshot
+	json.Unmarshal([]byte(data), &s)
+	if name != "line" || s.Index != 0 || s.Line != "One" {
+		t.Errorf("[tests/output/http/events] ERROR: Expected the current line first, got '%s' %s", name, data)
+	}
+
+	o.DisplayLyric(2)
+	name, data = next()
+	s = lrchttp.Snapshot{}
+	json.Unmarshal([]byte(data), &s)
+	if name != "line" || s.Index != 2 || s.Line != "Two" {
+		t.Errorf("[tests/output/http/events] ERROR: Expected the next line, got '%s' %s", name, data)
+	}
+
+	o.OnOverwrite("Offset: +0.5s")
+	name, data = next()
+	var ow lrchttp.Overwrite
+	json.Unmarshal([]byte(data), &ow)
+	if name != "overwrite" || ow.Text != "Offset: +0.5s" {
+		t.Errorf("[tests/output/http/events] ERROR: Expected the overwrite, got '%s' %s", name, data)
+	}
+}
@@ -0,0 +1,57 @@
+package mpv_test
+
+import (
+	"testing"
+	"time"
+
+	"lrcsnc/internal/output/mpv"
+	"lrcsnc/internal/pkg/structs"
+
+	mprislib "github.com/Endg4meZer0/go-mpris"
+)
+
+// TestLineDuration tests that a line is shown until the next one starts,
+// respecting the offsets and the playback rate.
+func TestLineDuration(t *testing.T) {
+	lyrics := []structs.Lyric{
+		{Time: 10, Text: "One"},
+		{Time: 14, Text: " "},
+		{Time: 16, Text: "Two"},
+	}
+	const fallback = 3 * time.Second
+
+	tests := []struct {
+		name            string
+		index           int
+		position        float64
+		rate            float64
+		status          mprislib.PlaybackStatus
+		timestampOffset float64
+		songOffset      float64
+		duration        time.Duration
+	}{
+		{name: "until-next", index: 0, position: 11, rate: 1, status: mprislib.PlaybackPlaying, duration: 3 * time.Second},
+		{name: "double-rate", index: 0, position: 11, rate: 2, status: mprislib.PlaybackPlaying, duration: 1500 * time.Millisecond},
+		{name: "no-rate", index: 0, position: 11, status: mprislib.PlaybackPlaying, duration: 3 * time.Second},
+		{name: "offsets", index: 0, position: 11, rate: 1, status: mprislib.PlaybackPlaying, timestampOffset: 0.5, songOffset: -1.5, duration: 2 * time.Second},
+		{name: "past-next", index: 0, position: 15, rate: 1, status: mprislib.PlaybackPlaying, duration: 0},
+		{name: "last-line", index: 2, position: 17, rate: 1, status: mprislib.PlaybackPlaying, duration: fallback},
+		{name: "paused", index: 0, position: 11, rate: 1, status: mprislib.PlaybackPaused, duration: fallback},
+		{name: "empty-line", index: 1, position: 15, rate: 1, status: mprislib.PlaybackPlaying, duration: 0},
+		{name: "before-lyrics", index: -1, position: 5, rate: 1, status: mprislib.PlaybackPlaying, duration: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := structs.Player{
+				PlaybackStatus: test.status,
+				Position:       test.position,
+				Rate:           test.rate,
+				Song:           structs.Song{LyricsData: structs.LyricsData{Lyrics: lyrics, Offset: test.songOffset}},
+			}
+			if d := mpv.LineDuration(p, test.index, test.timestampOffset, fallback); d != test.duration {
+				t.Errorf("[tests/output/mpv/duration/%v] ERROR: Expected %v, got %v", test.name, test.duration, d)
+			}
+		})
+	}
+}
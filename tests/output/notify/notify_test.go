@@ -0,0 +1,95 @@
+package notify_test
+
+import (
+	"testing"
+
+	"lrcsnc/internal/output/notify"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+var testSong = structs.Song{
+	Title:   "Song",
+	Artists: []string{"Artist"},
+	LyricsData: structs.LyricsData{
+		Lyrics: []structs.Lyric{
+			{Time: 1, Text: "One"},
+			{Time: 2, Text: " "},
+			{Time: 3, Text: "Two"},
+			{Time: 4, Text: "Three"},
+		},
+		LyricsState: types.LyricsStateSynced,
+	},
+}
+
+// TestSongNotification tests that a song is announced only in the "song" mode
+// and only once its lyrics are there.
+func TestSongNotification(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  types.NotifyMode
+		state types.LyricsState
+		lines uint
+		ok    bool
+		body  string
+	}{
+		{name: "song", mode: types.NotifySong, state: types.LyricsStateSynced, lines: 2, ok: true, body: "One\nTwo"},
+		{name: "song-plain", mode: types.NotifySong, state: types.LyricsStatePlain, lines: 5, ok: true, body: "One\nTwo\nThree"},
+		{name: "song-no-lines", mode: types.NotifySong, state: types.LyricsStateNotFound, ok: true, body: ""},
+		{name: "song-loading", mode: types.NotifySong, state: types.LyricsStateLoading, lines: 2},
+		{name: "song-unknown", mode: types.NotifySong, state: types.LyricsStateUnknown, lines: 2},
+		{name: "line-mode", mode: types.NotifyLine, state: types.LyricsStateSynced, lines: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			song := testSong
+			song.LyricsData.LyricsState = test.state
+			c := structs.NotifyOutputConfig{Mode: test.mode, Lines: test.lines, Summary: "{artist} - {title}"}
+
+			n, ok := notify.SongNotification(c, song)
+			if ok != test.ok {
+				t.Fatalf("[tests/output/notify/song/%v] ERROR: Expected a notification: %v, got %v", test.name, test.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if n.Summary != "Artist - Song" || n.Body != test.body {
+				t.Errorf("[tests/output/notify/song/%v] ERROR: Expected 'Artist - Song' with '%s', got '%s' with '%s'", test.name, test.body, n.Summary, n.Body)
+			}
+		})
+	}
+}
+
+// TestLineNotification tests that a line is announced only outside of the "song" mode
+// and only if it's sung.
+func TestLineNotification(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  types.NotifyMode
+		index int
+		ok    bool
+		body  string
+	}{
+		{name: "line", mode: types.NotifyLine, index: 0, ok: true, body: "One"},
+		{name: "line-last", mode: types.NotifyLine, index: 3, ok: true, body: "Three"},
+		{name: "line-empty", mode: types.NotifyLine, index: 1},
+		{name: "line-before-lyrics", mode: types.NotifyLine, index: -1},
+		{name: "line-after-lyrics", mode: types.NotifyLine, index: 4},
+		{name: "song-mode", mode: types.NotifySong, index: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := structs.NotifyOutputConfig{Mode: test.mode, Summary: "{title}"}
+
+			n, ok := notify.LineNotification(c, testSong, test.index)
+			if ok != test.ok {
+				t.Fatalf("[tests/output/notify/line/%v] ERROR: Expected a notification: %v, got %v", test.name, test.ok, ok)
+			}
+			if ok && (n.Summary != "Song" || n.Body != test.body) {
+				t.Errorf("[tests/output/notify/line/%v] ERROR: Expected 'Song' with '%s', got '%s' with '%s'", test.name, test.body, n.Summary, n.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package piped_test
+
+import (
+	"testing"
+
+	"lrcsnc/internal/output/piped/json"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+var i3barConfig = structs.JSONI3barOutputConfig{
+	Name:        "lrcsnc",
+	ClickEvents: true,
+	Colors:      map[string]string{"synced": "#ffffff", "not-found": "#888888"},
+	Urgent:      []string{"not-found"},
+	Clicks:      map[string]string{"1": "offset +0.5", "3": "offset reset"},
+}
+
+// TestI3barHeader tests that the header asks for the click events only if they are set.
+func TestI3barHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		clicks bool
+		result string
+	}{
+		{name: "clicks", clicks: true, result: `{"version":1,"click_events":true}`},
+		{name: "no-clicks", clicks: false, result: `{"version":1,"click_events":false}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &structs.PipedOutputConfig{JSONI3bar: structs.JSONI3barOutputConfig{ClickEvents: test.clicks}}
+			if result := json.FormatI3barHeader(c); result != test.result {
+				t.Errorf("[tests/output/piped/i3bar/header/%v] ERROR: Expected '%s', got '%s'", test.name, test.result, result)
+			}
+		})
+	}
+}
+
+// TestI3barBlock tests that the block is colored and marked urgent by the lyrics state.
+func TestI3barBlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  types.LyricsState
+		text   string
+		result string
+	}{
+		{name: "synced", state: types.LyricsStateSynced, text: "Never gonna", result: `[{"full_text":"Never gonna","name":"lrcsnc","color":"#ffffff"}]`},
+		{name: "not-found", state: types.LyricsStateNotFound, text: "No lyrics", result: `[{"full_text":"No lyrics","name":"lrcsnc","color":"#888888","urgent":true}]`},
+		{name: "no-color", state: types.LyricsStatePlain, text: "Plain", result: `[{"full_text":"Plain","name":"lrcsnc"}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			global.Player.M.Lock()
+			global.Player.P.Song = structs.Song{LyricsData: structs.LyricsData{LyricsState: test.state}}
+			c := &structs.PipedOutputConfig{JSON: types.JSONOutputI3bar, JSONI3bar: i3barConfig}
+			result := json.FormatToJSON(c, test.text, json.State{LyricIndex: -1})
+			global.Player.M.Unlock()
+
+			if result != test.result {
+				t.Errorf("[tests/output/piped/i3bar/block/%v] ERROR: Expected '%s', got '%s'", test.name, test.result, result)
+			}
+		})
+	}
+}
+
+// TestI3barClicks tests that the clicks on the block are read from the bar's stream
+// and mapped to the configured commands.
+func TestI3barClicks(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		command string
+		ok      bool
+		invalid bool
+	}{
+		{name: "first", line: `[{"name":"lrcsnc","button":1,"x":10,"y":5}`, command: "offset +0.5", ok: true},
+		{name: "next", line: `,{"name":"lrcsnc","button":3}`, command: "offset reset", ok: true},
+		{name: "unmapped-button", line: `,{"name":"lrcsnc","button":2}`},
+		{name: "other-block", line: `,{"name":"clock","button":1}`},
+		{name: "garbage", line: `,{"name":`, invalid: true},
+	}
+
+	c := &structs.PipedOutputConfig{JSON: types.JSONOutputI3bar, JSONI3bar: i3barConfig}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := json.ParseI3barClick(test.line)
+			if (err != nil) != test.invalid {
+				t.Fatalf("[tests/output/piped/i3bar/clicks/%v] ERROR: Expected the click to be invalid: %v, got %v", test.name, test.invalid, err)
+			}
+			if test.invalid {
+				return
+			}
+
+			command, ok := json.I3barClickCommand(c, e)
+			if command != test.command || ok != test.ok {
+				t.Errorf("[tests/output/piped/i3bar/clicks/%v] ERROR: Expected '%s' (%v), got '%s' (%v)", test.name, test.command, test.ok, command, ok)
+			}
+		})
+	}
+}
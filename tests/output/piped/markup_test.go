@@ -0,0 +1,35 @@
+package piped_test
+
+import (
+	"testing"
+
+	"lrcsnc/internal/output/piped/json"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// TestMarkup tests that the parts are escaped and styled only for Waybar.
+func TestMarkup(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     types.JSONOutputType
+		template string
+		part     string
+		result   string
+	}{
+		{name: "waybar-escaped", json: types.JSONOutputWaybar, part: `Rock & <Roll> "it's"`, result: "Rock &amp; &lt;Roll&gt; &quot;it&#39;s&quot;"},
+		{name: "waybar-styled", json: types.JSONOutputWaybar, template: "<b>{value}</b>", part: "Tom & Jerry", result: "<b>Tom &amp; Jerry</b>"},
+		{name: "waybar-empty-part", json: types.JSONOutputWaybar, template: "<b>{value}</b>", part: "", result: ""},
+		{name: "none-untouched", json: types.JSONOutputNone, template: "<b>{value}</b>", part: "Tom & Jerry", result: "Tom & Jerry"},
+		{name: "generic-untouched", json: types.JSONOutputGeneric, part: "<3", result: "<3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &structs.PipedOutputConfig{JSON: test.json}
+			if result := json.Style(c, test.template, json.Escape(c, test.part)); result != test.result {
+				t.Errorf("[tests/output/piped/markup/%v] ERROR: Expected '%s', got '%s'", test.name, test.result, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package overflow_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"lrcsnc/internal/output/piped/overflow"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// TestWidth tests that the width is counted in display cells without the markup.
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		markup bool
+		width  int
+	}{
+		{name: "ascii", text: "Never gonna", width: 11},
+		{name: "cjk", text: "日本語", width: 6},
+		{name: "emoji", text: "🎵 la", width: 5},
+		{name: "markup", text: "<b>Rock &amp; Roll</b>", markup: true, width: 11},
+		{name: "no-markup", text: "<b>", width: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if width := overflow.Width(test.text, test.markup); width != test.width {
+				t.Errorf("[tests/output/piped/overflow/width/%v] ERROR: Expected %d cells, got %d", test.name, test.width, width)
+			}
+		})
+	}
+}
+
+// TestWrap tests that the text is broken at the spaces, and anywhere if there are none.
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		parts []string
+	}{
+		{name: "words", text: "never gonna give you up", width: 11, parts: []string{"never gonna", "give you up"}},
+		{name: "long-word", text: "a supercalifragilistic b", width: 8, parts: []string{"a", "supercal", "ifragili", "stic b"}},
+		{name: "cjk", text: "日本語のテキスト", width: 5, parts: []string{"日本", "語の", "テキ", "スト"}},
+		{name: "too-narrow", text: "日本", width: 1, parts: []string{"日", "本"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if parts := overflow.Wrap(test.text, test.width); !slices.Equal(parts, test.parts) {
+				t.Errorf("[tests/output/piped/overflow/wrap/%v] ERROR: Expected %q, got %q", test.name, test.parts, parts)
+			}
+		})
+	}
+}
+
+// TestFit tests the overflow modes at different times of the line.
+func TestFit(t *testing.T) {
+	const lyric = "never gonna give you up" // 23 cells
+
+	tests := []struct {
+		name   string
+		config structs.OverflowConfig
+		// lyric is the default one if empty
+		lyric string
+		width int
+		line  *overflow.Line
+		text  string
+		left  float64
+	}{
+		{name: "fits", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 1}, width: 30, line: &overflow.Line{Length: 4}, text: lyric},
+		{name: "truncate", config: structs.OverflowConfig{Mode: types.OverflowTruncate, Ellipsis: "…"}, width: 12, text: "never gonna…"},
+		{name: "untimed-marquee", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 1, Ellipsis: "..."}, width: 12, text: "never gon..."},
+		{name: "wrap-first", config: structs.OverflowConfig{Mode: types.OverflowWrap}, width: 11, line: &overflow.Line{Elapsed: 1, Length: 4}, text: "never gonna", left: 1},
+		{name: "wrap-last", config: structs.OverflowConfig{Mode: types.OverflowWrap}, width: 11, line: &overflow.Line{Elapsed: 3, Length: 4}, text: "give you up"},
+		{name: "marquee-delay", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 10, Delay: 0.5}, width: 13, line: &overflow.Line{Elapsed: 0.2, Length: 10}, text: "never gonna g", left: 0.4},
+		{name: "marquee-scrolled", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 10, Delay: 0.5}, width: 13, line: &overflow.Line{Elapsed: 0.75, Length: 10}, text: "ver gonna giv", left: 0.05},
+		{name: "marquee-end", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 10, Delay: 0.5}, width: 13, line: &overflow.Line{Elapsed: 5, Length: 10}, text: "a give you up"},
+		// At 1 cell per second the 10 cells wouldn't be scrolled in 2 seconds, so it's sped up to 5
+		{name: "marquee-sped-up", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 1}, width: 13, line: &overflow.Line{Elapsed: 1, Length: 2}, text: " gonna give y", left: 0.2},
+		{name: "marquee-wide", config: structs.OverflowConfig{Mode: types.OverflowMarquee, Speed: 1}, lyric: "日本語", width: 5, line: &overflow.Line{Elapsed: 1, Length: 100}, text: "本語 "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := test.lyric
+			if text == "" {
+				text = lyric
+			}
+			result, left := overflow.Fit(test.config, text, test.width, test.line)
+			if result != test.text {
+				t.Errorf("[tests/output/piped/overflow/fit/%v] ERROR: Expected '%s', got '%s'", test.name, test.text, result)
+			}
+			if math.Abs(left-test.left) > 1e-9 {
+				t.Errorf("[tests/output/piped/overflow/fit/%v] ERROR: Expected the part to change in %.2fs, got %.2fs", test.name, test.left, left)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package socket_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lrcsnc/internal/output/socket"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// event is any of the published events
+type event struct {
+	Event string `json:"event"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// setup makes the output publish on a socket in a temporary directory
+// and the player play a song with the lyrics
+func setup(t *testing.T, lyrics []structs.Lyric) (*socket.Output, string) {
+	p := filepath.Join(t.TempDir(), "events.sock")
+
+	global.Config.M.Lock()
+	global.Config.C.Output.Instances = nil
+	global.Config.C.Output.Type = types.OutputSocket
+	global.Config.C.Output.Socket = structs.SocketOutputConfig{Path: p}
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	global.Player.P.Name = "fake"
+	global.Player.P.Position = 0
+	global.Player.P.Song = structs.Song{
+		Title: "Song",
+		LyricsData: structs.LyricsData{
+			Lyrics:      lyrics,
+			LyricsState: types.LyricsStateSynced,
+		},
+	}
+	global.Player.M.Unlock()
+
+	o, err := socket.New("default")
+	if err != nil {
+		t.Fatalf("[tests/output/socket] ERROR: Failed to create the output: %v", err)
+	}
+	t.Cleanup(o.Close)
+	return o, p
+}
+
+// connect connects a client to the socket and returns a reader of its events
+func connect(t *testing.T, p string) (net.Conn, *bufio.Reader) {
+	c, err := net.Dial("unix", p)
+	if err != nil {
+		t.Fatalf("[tests/output/socket] ERROR: Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, bufio.NewReaderSize(c, 1<<20)
+}
+
+// next reads the next event of the client
+func next(t *testing.T, c net.Conn, r *bufio.Reader) (event, error) {
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return event{}, err
+	}
+	var e event
+	if err := json.Unmarshal(line, &e); err != nil {
+		t.Fatalf("[tests/output/socket] ERROR: Failed to parse the event '%s': %v", line, err)
+	}
+	return e, nil
+}
+
+// TestInitialState tests that a new client gets the last event of every kind first.
+func TestInitialState(t *testing.T) {
+	o, p := setup(t, []structs.Lyric{{Time: 1, Text: "One"}, {Time: 2, Text: "Two"}})
+	o.DisplayLyric(1)
+
+	c, r := connect(t, p)
+	expected := []event{
+		{Event: "player", Name: "fake"},
+		{Event: "song", Title: "Song"},
+		{Event: "state"},
+		{Event: "line", Index: 1, Text: "Two"},
+	}
+	for _, want := range expected {
+		e, err := next(t, c, r)
+		if err != nil {
+			t.Fatalf("[tests/output/socket/initial] ERROR: Expected a %s event, got %v", want.Event, err)
+		}
+		if e != want {
+			t.Errorf("[tests/output/socket/initial] ERROR: Expected %+v, got %+v", want, e)
+		}
+	}
+}
+
+// TestFanOut tests that every client gets the events, except for the repeated ones.
+func TestFanOut(t *testing.T) {
+	o, p := setup(t, []structs.Lyric{{Time: 1, Text: "One"}, {Time: 2, Text: "Two"}})
+
+	clients := make([]struct {
+		c net.Conn
+		r *bufio.Reader
+	}, 3)
+	for i := range clients {
+		clients[i].c, clients[i].r = connect(t, p)
+	}
+	// Every client is surely accepted once it gets the initial state
+	for i, cl := range clients {
+		for range 4 {
+			if _, err := next(t, cl.c, cl.r); err != nil {
+				t.Fatalf("[tests/output/socket/fan-out] ERROR: The client %d didn't get the initial state: %v", i, err)
+			}
+		}
+	}
+
+	for _, i := range []int{0, 0, 1} {
+		global.Player.M.Lock()
+		global.Player.P.Position = float64(i + 1)
+		global.Player.M.Unlock()
+		o.DisplayLyric(i)
+	}
+
+	expected := []event{
+		{Event: "line", Index: 0, Text: "One"},
+		{Event: "state"},
+		{Event: "line", Index: 1, Text: "Two"},
+		{Event: "state"},
+	}
+	for i, cl := range clients {
+		for _, want := range expected {
+			e, err := next(t, cl.c, cl.r)
+			if err != nil {
+				t.Fatalf("[tests/output/socket/fan-out] ERROR: Expected the client %d to get %+v, got %v", i, want, err)
+			}
+			if e != want {
+				t.Errorf("[tests/output/socket/fan-out] ERROR: Expected the client %d to get %+v, got %+v", i, want, e)
+			}
+		}
+	}
+}
+
+// TestSlowClient tests that a client that doesn't read the events is disconnected
+// without holding up the others.
+func TestSlowClient(t *testing.T) {
+	// Big lines fill the socket buffers fast
+	lyrics := []structs.Lyric{{Text: strings.Repeat("a", 64<<10)}, {Text: strings.Repeat("b", 64<<10)}}
+	const published = 200
+	o, p := setup(t, lyrics)
+
+	slow, slowReader := connect(t, p)
+	fast, fastReader := connect(t, p)
+
+	// The fast client reads everything as it comes
+	lines := make(chan int, published)
+	go func() {
+		defer close(lines)
+		for {
+			e, err := next(t, fast, fastReader)
+			if err != nil {
+				return
+			}
+			if e.Event == "line" {
+				lines <- e.Index
+			}
+		}
+	}()
+	// Both clients are accepted once the fast one gets the initial line
+	if <-lines != -1 {
+		t.Fatal("[tests/output/socket/slow-client] ERROR: Expected the initial line first")
+	}
+
+	for i := range published {
+		o.DisplayLyric(i % 2)
+		time.Sleep(time.Millisecond)
+	}
+
+	received := 0
+	for range lines {
+		received++
+		if received == published {
+			break
+		}
+	}
+	if received != published {
+		t.Errorf("[tests/output/socket/slow-client] ERROR: Expected the fast client to get %d lines, got %d", published, received)
+	}
+
+	// The slow client gets whatever was buffered, and then the connection is closed
+	slowLines := 0
+	for {
+		e, err := next(t, slow, slowReader)
+		if err != nil {
+			break
+		}
+		if e.Event == "line" {
+			slowLines++
+		}
+	}
+	if slowLines >= published {
+		t.Errorf("[tests/output/socket/slow-client] ERROR: Expected the slow client to be disconnected, got all %d lines", slowLines)
+	}
+}
@@ -0,0 +1,54 @@
+package tui_test
+
+import (
+	"testing"
+
+	"lrcsnc/internal/control"
+	"lrcsnc/internal/output/tui"
+	"lrcsnc/internal/pkg/structs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestKeyCommand tests that the keys send the control commands with the configured steps
+// and that the keys of the TUI itself send nothing.
+func TestKeyCommand(t *testing.T) {
+	c := structs.TUIOutputConfig{SeekStep: 5, OffsetStep: 0.1}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	tests := []struct {
+		name    string
+		msg     tea.KeyMsg
+		command control.CommandType
+		data    any
+		ok      bool
+	}{
+		{name: "play-pause", msg: tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, command: control.CommandPlayer, data: control.PlayerPlayPause, ok: true},
+		{name: "seek-back", msg: tea.KeyMsg{Type: tea.KeyLeft}, command: control.CommandSeekRelative, data: -5.0, ok: true},
+		{name: "seek-back-h", msg: runes("h"), command: control.CommandSeekRelative, data: -5.0, ok: true},
+		{name: "seek-forward", msg: runes("l"), command: control.CommandSeekRelative, data: 5.0, ok: true},
+		{name: "previous-line", msg: runes("p"), command: control.CommandSeekRelativeLine, data: -1, ok: true},
+		{name: "next-line", msg: runes("n"), command: control.CommandSeekRelativeLine, data: 1, ok: true},
+		{name: "offset-up", msg: runes("+"), command: control.CommandAdjustOffset, data: 0.1, ok: true},
+		{name: "offset-up-equals", msg: runes("="), command: control.CommandAdjustOffset, data: 0.1, ok: true},
+		{name: "offset-down", msg: runes("-"), command: control.CommandAdjustOffset, data: -0.1, ok: true},
+		{name: "offset-reset", msg: runes("0"), command: control.CommandResetOffset, ok: true},
+		{name: "refetch", msg: runes("r"), command: control.CommandRefetch, ok: true},
+		{name: "scroll", msg: runes("j")},
+		{name: "help", msg: runes("?")},
+		{name: "quit", msg: tea.KeyMsg{Type: tea.KeyCtrlC}},
+		{name: "unbound", msg: runes("x")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			command, data, ok := tui.KeyCommand(test.msg, c)
+			if ok != test.ok {
+				t.Fatalf("[tests/output/tui/keys/%v] ERROR: Expected the key to send a command: %v, got %v", test.name, test.ok, ok)
+			}
+			if ok && (command != test.command || data != test.data) {
+				t.Errorf("[tests/output/tui/keys/%v] ERROR: Expected the command %v with %v, got %v with %v", test.name, test.command, test.data, command, data)
+			}
+		})
+	}
+}
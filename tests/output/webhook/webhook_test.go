@@ -0,0 +1,117 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lrcsnc/internal/output/webhook"
+	"lrcsnc/internal/pkg/global"
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/pkg/types"
+)
+
+// TestSend tests that the failed requests are retried only when it's worth it.
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		delay    time.Duration
+		retries  uint
+		fail     bool
+		requests int32
+	}{
+		{name: "ok", statuses: []int{http.StatusNoContent}, requests: 1},
+		{name: "retried", statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}, retries: 3, requests: 3},
+		{name: "gave-up", statuses: []int{http.StatusBadGateway}, retries: 1, fail: true, requests: 2},
+		{name: "client-error", statuses: []int{http.StatusBadRequest}, retries: 3, fail: true, requests: 1},
+		{name: "timeout", statuses: []int{http.StatusOK}, delay: 300 * time.Millisecond, fail: true, requests: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				time.Sleep(test.delay)
+				w.WriteHeader(test.statuses[min(n, len(test.statuses))-1])
+			}))
+			defer server.Close()
+
+			c := structs.WebhookOutputConfig{URLs: []string{server.URL}, Timeout: 0.1, Retries: test.retries, RetryDelay: 0.01}
+			err := webhook.Send(c, []webhook.Event{{Event: types.WebhookLine}})
+
+			if (err != nil) != test.fail {
+				t.Errorf("[tests/output/webhook/send/%v] ERROR: Expected failure to be %v, got %v", test.name, test.fail, err)
+			}
+			if n := requests.Load(); n != test.requests {
+				t.Errorf("[tests/output/webhook/send/%v] ERROR: Expected %d requests, got %d", test.name, test.requests, n)
+			}
+		})
+	}
+}
+
+// TestBatching tests that the events within the batch time are sent together
+// and that only the configured kinds are sent.
+func TestBatching(t *testing.T) {
+	var m sync.Mutex
+	var payloads []webhook.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhook.Payload
+		json.NewDecoder(r.Body).Decode(&p)
+		m.Lock()
+		payloads = append(payloads, p)
+		m.Unlock()
+	}))
+	defer server.Close()
+
+	// The output's worker reads both under their mutexes
+	global.Config.M.Lock()
+	global.Config.C.Output.Instances = nil
+	global.Config.C.Output.Type = types.OutputWebhook
+	global.Config.C.Output.Webhook = structs.WebhookOutputConfig{
+		URLs:    []string{server.URL},
+		Events:  []types.WebhookEventType{types.WebhookLine},
+		Batch:   0.2,
+		Timeout: 1,
+	}
+	global.Config.M.Unlock()
+
+	global.Player.M.Lock()
+	global.Player.P.Name = "fake"
+	global.Player.P.Song = structs.Song{
+		Title: "Song",
+		LyricsData: structs.LyricsData{
+			Lyrics:      []structs.Lyric{{Text: "One"}, {Text: "Two"}, {Text: "Three"}},
+			LyricsState: types.LyricsStateSynced,
+		},
+	}
+	global.Player.M.Unlock()
+
+	o := webhook.New("default")
+	defer o.Close()
+
+	o.OnPlayerUpdate()
+	for _, i := range []int{0, 1, 1, 2} {
+		o.DisplayLyric(i)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	m.Lock()
+	defer m.Unlock()
+	if len(payloads) != 1 {
+		t.Fatalf("[tests/output/webhook/batching] ERROR: Expected 1 request, got %d", len(payloads))
+	}
+	if len(payloads[0].Events) != 3 {
+		t.Fatalf("[tests/output/webhook/batching] ERROR: Expected 3 events, got %d", len(payloads[0].Events))
+	}
+	for i, e := range payloads[0].Events {
+		if e.Event != types.WebhookLine || e.Line == nil || e.Line.Index != i {
+			t.Errorf("[tests/output/webhook/batching] ERROR: Expected a line event for the line %d, got %+v", i, e)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package sync_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"lrcsnc/internal/sync"
+)
+
+// seconds converts the seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// TestClockRefinesCoarsePosition tests that the position of a player reporting it
+// in whole seconds is narrowed down by the readings the clock asks for.
+func TestClockRefinesCoarsePosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  float64
+		rate   float64
+		coarse bool
+	}{
+		{name: "coarse", start: 10.37, rate: 1, coarse: true},
+		{name: "coarse-double-rate", start: 42.81, rate: 2, coarse: true},
+		{name: "coarse-half-rate", start: 5.05, rate: 0.5, coarse: true},
+		{name: "coarse-almost-whole", start: 7.99, rate: 1, coarse: true},
+		{name: "precise", start: 10.37, rate: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := sync.NewPlaybackClock()
+			c.Restart(0, test.rate, true)
+
+			start := time.Now()
+			now := start
+			truePosition := func() float64 {
+				return test.start + now.Sub(start).Seconds()*test.rate
+			}
+			reading := func() float64 {
+				if test.coarse {
+					return math.Floor(truePosition())
+				}
+				return truePosition()
+			}
+
+			readings := 1
+			delay, ok := c.Observe(reading(), now)
+			for ok {
+				if readings > 20 {
+					t.Fatalf("[tests/sync/clock/%v] ERROR: The position is still not refined after %v readings", test.name, readings)
+				}
+				now = now.Add(delay)
+				delay, ok = c.Observe(reading(), now)
+				readings++
+			}
+
+			if !test.coarse && readings != 1 {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected a precise player to be read once, got %v readings", test.name, readings)
+			}
+			if d := math.Abs(c.PositionAt(now) - truePosition()); d > 0.05 {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected the position to be within 0.05s of %.3f, got %.3f", test.name, truePosition(), c.PositionAt(now))
+			}
+		})
+	}
+}
+
+// TestClockExtrapolates tests that the position moves with the playback rate
+// and stays in place while the playback is paused.
+func TestClockExtrapolates(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		playing  bool
+		after    float64
+		expected float64
+	}{
+		{name: "normal", rate: 1, playing: true, after: 2, expected: 12.5},
+		{name: "double-rate", rate: 2, playing: true, after: 2, expected: 14.5},
+		{name: "half-rate", rate: 0.5, playing: true, after: 2, expected: 11.5},
+		{name: "paused", rate: 1, playing: false, after: 2, expected: 10.5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := sync.NewPlaybackClock()
+			c.Restart(0, test.rate, test.playing)
+
+			start := time.Now()
+			c.Observe(10.5, start)
+
+			if got := c.PositionAt(start.Add(seconds(test.after))); math.Abs(got-test.expected) > 1e-6 {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected the position %v after %vs, got %v", test.name, test.expected, test.after, got)
+			}
+			if c.Rate() != test.rate || c.Playing() != test.playing {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected the rate %v and playing %v, got %v and %v", test.name, test.rate, test.playing, c.Rate(), c.Playing())
+			}
+		})
+	}
+}
+
+// TestClockDrift tests how far the player's readings are from the extrapolated position
+// and whether they are over the drift threshold.
+func TestClockDrift(t *testing.T) {
+	tests := []struct {
+		name string
+		// coarse makes the player report the position in whole seconds
+		coarse    bool
+		rate      float64
+		reading   float64
+		threshold float64
+		drift     float64
+		corrected bool
+	}{
+		{name: "on-time", rate: 1, reading: 12.5, threshold: 0.3, drift: 0},
+		{name: "ahead", rate: 1, reading: 12.7, threshold: 0.3, drift: 0.2},
+		{name: "ahead-over-threshold", rate: 1, reading: 13, threshold: 0.3, drift: 0.5, corrected: true},
+		{name: "behind-over-threshold", rate: 1, reading: 11.5, threshold: 0.3, drift: -1, corrected: true},
+		{name: "double-rate", rate: 2, reading: 14.5, threshold: 0.3, drift: 0},
+		{name: "double-rate-behind", rate: 2, reading: 12.5, threshold: 0.3, drift: -2, corrected: true},
+		// The coarse player is at [12, 13) after the two readings, then 2s pass
+		{name: "coarse-within-range", coarse: true, rate: 1, reading: 14, threshold: 0.3, drift: 0},
+		{name: "coarse-ahead", coarse: true, rate: 1, reading: 16, threshold: 0.3, drift: 1, corrected: true},
+		{name: "coarse-behind", coarse: true, rate: 1, reading: 12, threshold: 0.3, drift: -1, corrected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := sync.NewPlaybackClock()
+			c.Restart(0, test.rate, true)
+
+			start := time.Now()
+			if test.coarse {
+				c.Observe(11, start.Add(seconds(-1/test.rate)))
+				c.Observe(12, start)
+			} else {
+				c.Observe(10.5, start)
+			}
+
+			d := c.Drift(test.reading, start.Add(seconds(2)))
+			if math.Abs(d-test.drift) > 1e-6 {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected the drift %v, got %v", test.name, test.drift, d)
+			}
+			if corrected := math.Abs(d) > test.threshold; corrected != test.corrected {
+				t.Errorf("[tests/sync/clock/%v] ERROR: Expected the drift %v to be corrected: %v", test.name, d, test.corrected)
+			}
+		})
+	}
+}
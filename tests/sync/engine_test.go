@@ -0,0 +1,256 @@
+package sync_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"lrcsnc/internal/pkg/structs"
+	"lrcsnc/internal/practice"
+	"lrcsnc/internal/sync"
+)
+
+// fakeClock is a playback clock that only moves when told to
+type fakeClock struct {
+	position float64
+	rate     float64
+	playing  bool
+}
+
+func (c *fakeClock) Position() float64 { return c.position }
+func (c *fakeClock) Rate() float64     { return c.rate }
+func (c *fakeClock) Playing() bool     { return c.playing }
+
+func (c *fakeClock) advance(d time.Duration) {
+	if c.playing {
+		c.position += d.Seconds() * c.rate
+	}
+}
+
+var testLyrics = []structs.Lyric{
+	{Time: 1, Text: "One"},
+	{Time: 3, Text: "Two"},
+	{Time: 3, Text: "Two and a half"},
+	{Time: 6, Text: "Three"},
+	{Time: 10, Text: "Four"},
+}
+
+// run ticks the engine the way the lyrics synchronizer does, advancing the clock
+// by the wait of each step until the position is past the limit,
+// and returns the displayed line indexes and the waits.
+func run(e *sync.Engine, c *fakeClock, limit float64) (displayed []int, waits []time.Duration) {
+	for c.position <= limit {
+		step := e.Tick()
+		if step.Display {
+			displayed = append(displayed, step.Index)
+		}
+		if step.Wait == 0 {
+			break
+		}
+		waits = append(waits, step.Wait)
+		c.advance(step.Wait)
+	}
+	return
+}
+
+// TestEngineFollowsLines tests that the engine displays every line right when it starts,
+// respecting the offset and the playback rate.
+func TestEngineFollowsLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      float64
+		offset    float64
+		displayed []int
+		firstWait time.Duration
+	}{
+		{name: "normal", rate: 1, displayed: []int{-1, 0, 2, 3, 4}, firstWait: time.Second + time.Millisecond},
+		{name: "offset", rate: 1, offset: 0.5, displayed: []int{-1, 0, 2, 3, 4}, firstWait: 1500*time.Millisecond + time.Millisecond},
+		{name: "double-rate", rate: 2, displayed: []int{-1, 0, 2, 3, 4}, firstWait: 500*time.Millisecond + time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &fakeClock{rate: test.rate, playing: true}
+			e := sync.NewEngine(c)
+			e.SetLyrics(testLyrics, true)
+			e.SetOffset(test.offset)
+
+			displayed, waits := run(e, c, 11)
+			if !slices.Equal(displayed, test.displayed) {
+				t.Errorf("[tests/sync/engine/%v] ERROR: Expected the lines %v to be displayed, got %v", test.name, test.displayed, displayed)
+			}
+			if len(waits) == 0 || waits[0] != test.firstWait {
+				t.Errorf("[tests/sync/engine/%v] ERROR: Expected the first wait to be %v, got %v", test.name, test.firstWait, waits)
+			}
+			if e.State() != sync.StateFollowing {
+				t.Errorf("[tests/sync/engine/%v] ERROR: Expected the engine to be following, got state %v", test.name, e.State())
+			}
+		})
+	}
+}
+
+// TestEngineLineAt tests the lookup of the current line at various positions.
+func TestEngineLineAt(t *testing.T) {
+	tests := []struct {
+		position float64
+		index    int
+	}{
+		{position: 0, index: -1},
+		{position: 0.999, index: -1},
+		{position: 1, index: 0},
+		{position: 2.5, index: 0},
+		// Of the lines starting at the same time the last one is current
+		{position: 3, index: 2},
+		{position: 9.99, index: 3},
+		{position: 10, index: 4},
+		{position: 500, index: 4},
+	}
+
+	for _, test := range tests {
+		c := &fakeClock{position: test.position, rate: 1, playing: true}
+		e := sync.NewEngine(c)
+		e.SetLyrics(testLyrics, true)
+
+		if step := e.Tick(); step.Index != test.index {
+			t.Errorf("[tests/sync/engine/lineAt] ERROR: Expected the line %d at %.3fs, got %d", test.index, test.position, step.Index)
+		}
+	}
+}
+
+// TestEngineStill tests that a paused engine doesn't schedule anything
+// and only displays line changes on demand.
+func TestEngineStill(t *testing.T) {
+	c := &fakeClock{position: 2, rate: 1, playing: true}
+	e := sync.NewEngine(c)
+	e.SetLyrics(testLyrics, true)
+
+	if step := e.Tick(); !step.Display || step.Index != 0 {
+		t.Fatalf("[tests/sync/engine/still] ERROR: Expected the line 0 to be displayed, got %+v", step)
+	}
+
+	c.playing = false
+	c.position = 7
+	step := e.Tick()
+	if step.Display || step.Wait != 0 || step.Index != 3 || e.State() != sync.StateStill {
+		t.Errorf("[tests/sync/engine/still] ERROR: Expected the line 3 to be found silently with nothing scheduled, got %+v in state %v", step, e.State())
+	}
+
+	e.Redisplay()
+	if step := e.Tick(); !step.Display || step.Index != 3 {
+		t.Errorf("[tests/sync/engine/still] ERROR: Expected the line 3 to be displayed on demand, got %+v", step)
+	}
+
+	// A rate of 0 is no different from a pause
+	c.playing = true
+	c.rate = 0
+	if step := e.Tick(); step.Wait != 0 || e.State() != sync.StateStill {
+		t.Errorf("[tests/sync/engine/still] ERROR: Expected nothing to be scheduled at the rate of 0, got %+v in state %v", step, e.State())
+	}
+}
+
+// TestEngineLoop tests that the engine wakes up at the end of the practice loop
+// and tells that it has ended.
+func TestEngineLoop(t *testing.T) {
+	c := &fakeClock{position: 3.5, rate: 1, playing: true}
+	e := sync.NewEngine(c)
+	e.SetLyrics(testLyrics, true)
+	// Looping the lines 1-2 ends where the line 3 starts
+	e.SetLoopEnd(6)
+
+	step := e.Tick()
+	if step.Wait != 2500*time.Millisecond+time.Millisecond {
+		t.Errorf("[tests/sync/engine/loop] ERROR: Expected to wait until the end of the loop, got %v", step.Wait)
+	}
+
+	c.advance(step.Wait)
+	if step := e.Tick(); !step.LoopEnded || step.Display {
+		t.Errorf("[tests/sync/engine/loop] ERROR: Expected the loop to end without displaying the next line, got %+v", step)
+	}
+
+	e.ClearLoop()
+	if step := e.Tick(); step.LoopEnded || !step.Display || step.Index != 3 {
+		t.Errorf("[tests/sync/engine/loop] ERROR: Expected the line 3 to be displayed without the loop, got %+v", step)
+	}
+}
+
+// TestEngineNoLyrics tests the engine without synced lyrics and the switch to new lyrics.
+func TestEngineNoLyrics(t *testing.T) {
+	c := &fakeClock{position: 4, rate: 1, playing: true}
+	e := sync.NewEngine(c)
+	e.SetLyrics(testLyrics, false)
+
+	if step := e.Tick(); !step.Display || step.Index != -1 || step.Wait != 0 || e.State() != sync.StateNoLyrics {
+		t.Errorf("[tests/sync/engine/noLyrics] ERROR: Expected nothing to be displayed or scheduled, got %+v in state %v", step, e.State())
+	}
+
+	e.SetLyrics(testLyrics, true)
+	if step := e.Tick(); !step.Display || step.Index != 2 {
+		t.Errorf("[tests/sync/engine/noLyrics] ERROR: Expected the line 2 to be displayed, got %+v", step)
+	}
+
+	// The same line of other lyrics is still a new line to display
+	other := slices.Clone(testLyrics)
+	e.SetLyrics(other, true)
+	if step := e.Tick(); !step.Display || step.Index != 2 {
+		t.Errorf("[tests/sync/engine/noLyrics] ERROR: Expected the line 2 of new lyrics to be displayed, got %+v", step)
+	}
+}
+
+// TestEngineOutro tests that the outro starts after the last line has lasted long enough
+// while the current line stays the last one.
+func TestEngineOutro(t *testing.T) {
+	c := &fakeClock{position: 10, rate: 1, playing: true}
+	e := sync.NewEngine(c)
+	e.SetLyrics(testLyrics, true)
+	e.SetOutro(3)
+
+	step := e.Tick()
+	if !step.Display || step.Index != 4 || step.Wait != 3*time.Second+time.Millisecond {
+		t.Errorf("[tests/sync/engine/outro] ERROR: Expected the last line to be displayed until the outro, got %+v", step)
+	}
+
+	c.advance(step.Wait)
+	if step := e.Tick(); !step.Display || step.Index != len(testLyrics) || e.Index() != 4 {
+		t.Errorf("[tests/sync/engine/outro] ERROR: Expected the outro to be displayed with the last line being current, got %+v and %d", step, e.Index())
+	}
+
+	e.ClearOutro()
+	if step := e.Tick(); !step.Display || step.Index != 4 {
+		t.Errorf("[tests/sync/engine/outro] ERROR: Expected the last line to be displayed again without the outro, got %+v", step)
+	}
+}
+
+// TestEngineLoopLastLine tests that a practice loop including the last line
+// ends before the song does, so the playback is sent back before the player ends the track.
+func TestEngineLoopLastLine(t *testing.T) {
+	song := structs.Song{Duration: 12}
+	song.LyricsData.Lyrics = testLyrics
+
+	end := sync.PracticeLoopEnd(practice.Loop{Start: 3, End: 4}, song, 0)
+	if end >= song.Duration || end <= testLyrics[4].Time {
+		t.Fatalf("[tests/sync/engine/loopLastLine] ERROR: Expected the loop to end between the last line and the end of the song, got %.2fs", end)
+	}
+
+	c := &fakeClock{position: 10, rate: 1, playing: true}
+	e := sync.NewEngine(c)
+	e.SetLyrics(testLyrics, true)
+	e.SetLoopEnd(end)
+
+	ended := false
+	for c.position < song.Duration {
+		step := e.Tick()
+		if step.LoopEnded {
+			ended = true
+			break
+		}
+		c.advance(step.Wait)
+	}
+	if !ended {
+		t.Errorf("[tests/sync/engine/loopLastLine] ERROR: Expected the loop to end before the song ended at %.2fs", c.position)
+	}
+
+	// The lines 1 and 2 start at the same time, so looping the line 1 alone ends where it starts
+	if end := sync.PracticeLoopEnd(practice.Loop{Start: 1, End: 1}, song, 0); end > testLyrics[1].Time {
+		t.Errorf("[tests/sync/engine/loopLastLine] ERROR: Expected the loop of the line 1 to be empty, got the end at %.2fs", end)
+	}
+}
@@ -0,0 +1,97 @@
+package template_test
+
+import (
+	"strings"
+	"testing"
+
+	"lrcsnc/internal/pkg/template"
+)
+
+// TestRender tests the variables, filters and conditions of the templates.
+func TestRender(t *testing.T) {
+	vars := template.Vars{
+		"lyric":      "Never gonna give you up",
+		"multiplier": "(x2)",
+		"title":      "together forever",
+		"album":      "",
+		"artist":     "Rick Astley",
+		"wide":       "夜に駆ける",
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		result string
+	}{
+		{name: "plain", format: "{lyric} {multiplier}", result: "Never gonna give you up (x2)"},
+		{name: "unknown", format: "[{nothing}]", result: "[]"},
+		{name: "braces", format: "{{{lyric|trunc:5}}}", result: "{Neve…}"},
+		{name: "if", format: "{if multiplier}x{end}{if album}y{end}", result: "x"},
+		{name: "if-else", format: "{if album}{album}{else}no album{end}", result: "no album"},
+		{name: "if-not", format: "{if !album}-{end}", result: "-"},
+		{name: "nested", format: "{if title}{if album}a{else}b{end}{end}", result: "b"},
+		{name: "case", format: "{title|upper} {title|title} {artist|lower}", result: "TOGETHER FOREVER Together Forever rick astley"},
+		{name: "trunc", format: "{lyric|trunc:11}", result: "Never gonn…"},
+		{name: "trunc-ellipsis", format: "{lyric|trunc:10:...}", result: "Never g..."},
+		{name: "trunc-short", format: "{artist|trunc:20}", result: "Rick Astley"},
+		{name: "pad", format: "[{artist|pad:13}][{artist|lpad:13}][{artist|center:14}]", result: "[Rick Astley  ][  Rick Astley][ Rick Astley  ]"},
+		{name: "trunc-wide", format: "{wide|trunc:7}", result: "夜に駆…"},
+		{name: "trunc-wide-odd", format: "{wide|trunc:6:.}", result: "夜に."},
+		{name: "pad-wide", format: "[{wide|pad:12}][{wide|lpad:12}][{wide|center:13}]", result: "[夜に駆ける  ][  夜に駆ける][ 夜に駆ける  ]"},
+		{name: "default", format: "{album|default:Single: yes}", result: "Single: yes"},
+		{name: "or", format: "{album|or:title|upper}", result: "TOGETHER FOREVER"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := template.Render(test.format, vars, nil); result != test.result {
+				t.Errorf("[tests/template/render/%v] ERROR: Expected '%s', got '%s'", test.name, test.result, result)
+			}
+		})
+	}
+}
+
+// TestFinish tests that the values are finished after their filters.
+func TestFinish(t *testing.T) {
+	finish := func(name, value string) string { return "<" + name + ":" + value + ">" }
+	if result := template.Render("{lyric|trunc:3:} x", template.Vars{"lyric": "abcdef"}, finish); result != "<lyric:abc> x" {
+		t.Errorf("[tests/template/finish] ERROR: Expected '<lyric:abc> x', got '%s'", result)
+	}
+}
+
+// TestValidate tests that the invalid templates are reported with a helpful error.
+func TestValidate(t *testing.T) {
+	names := []string{"lyric", "multiplier", "title"}
+
+	tests := []struct {
+		name   string
+		format string
+		// error is a part of the expected error, or empty if the format is valid
+		error string
+	}{
+		{name: "valid", format: "{if multiplier}{lyric|trunc:20} {multiplier}{else}{title|or:lyric}{end}"},
+		{name: "misspelled", format: "{lyirc}", error: "did you mean 'lyric'"},
+		{name: "unknown-in-if", format: "{if album}{end}", error: "'album' is not a known variable"},
+		{name: "unknown-in-or", format: "{title|or:album}", error: "'album' is not a known variable"},
+		{name: "unclosed", format: "{lyric", error: "at character 1: '{' is never closed"},
+		{name: "stray-brace", format: "lyric}", error: "at character 6"},
+		{name: "unclosed-if", format: "{if lyric}x", error: "never closed with {end}"},
+		{name: "stray-end", format: "x{end}", error: "{end} without {if}"},
+		{name: "unknown-filter", format: "{lyric|shout}", error: "'shout' is not a known filter"},
+		{name: "missing-argument", format: "{lyric|trunc}", error: "'trunc' needs an argument"},
+		{name: "bad-argument", format: "{lyric|pad:wide}", error: "not a positive number"},
+		{name: "extra-argument", format: "{lyric|upper:1}", error: "takes no arguments"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := template.Validate(test.format, names)
+			switch {
+			case test.error == "" && err != nil:
+				t.Errorf("[tests/template/validate/%v] ERROR: Expected no error, got '%v'", test.name, err)
+			case test.error != "" && (err == nil || !strings.Contains(err.Error(), test.error)):
+				t.Errorf("[tests/template/validate/%v] ERROR: Expected an error with '%s', got '%v'", test.name, test.error, err)
+			}
+		})
+	}
+}